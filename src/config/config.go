@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tk "godot_linter/styler/tokendef"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileName is the project config looked up from the input path upwards
+const FileName = ".gdbeautify.toml"
+
+// DefaultLineLength is the longest line the style guide recommends
+const DefaultLineLength = 100
+
+// How members between `#region` and `#endregion` are ordered
+const (
+	// A region moves as a whole to the place of its first member, its members are ordered within it
+	RegionsGroup = "group"
+	// Regions stay where they are, members are ordered without crossing a region's bounds
+	RegionsFixed = "fixed"
+)
+
+type Config struct {
+	// Block order from first to last, every block type appears exactly once
+	Order []tk.BlockType
+
+	// One of RegionsGroup or RegionsFixed
+	Regions string
+
+	// Indent comments inside a body that are indented less than the code below them
+	ReindentComments bool
+
+	// Lines longer than this are broken at their brackets, 0 turns wrapping off and nil uses DefaultLineLength
+	LineLength *int
+
+	// Most blank lines in a row inside a body, nil uses 1
+	MaxBlankLines *int
+}
+
+// file is the on-disk layout of FileName
+type file struct {
+	Order            []string `toml:"order"`
+	Regions          string   `toml:"regions"`
+	ReindentComments bool     `toml:"reindent_comments"`
+	LineLength       *int     `toml:"line_length"`
+	MaxBlankLines    *int     `toml:"max_blank_lines"`
+}
+
+// Default returns the config used without a config file, blocks ordered by enum value.
+func Default() Config {
+	var order []tk.BlockType
+	for bt := tk.BlockType(0); bt <= tk.Unknown; bt++ {
+		order = append(order, bt)
+	}
+	return Config{Order: order, Regions: RegionsGroup, LineLength: intPtr(DefaultLineLength), MaxBlankLines: intPtr(1)}
+}
+
+// WithDefaults returns the config with everything it leaves unset taken from Default,
+// types its order leaves out follow the listed ones in their default order.
+func (c Config) WithDefaults() Config {
+	def := Default()
+	if len(c.Order) > 0 {
+		c.Order = CompleteOrder(c.Order)
+	} else {
+		c.Order = def.Order
+	}
+	if c.Regions == "" {
+		c.Regions = def.Regions
+	}
+	if c.LineLength == nil {
+		c.LineLength = def.LineLength
+	}
+	if c.MaxBlankLines == nil {
+		c.MaxBlankLines = def.MaxBlankLines
+	}
+	return c
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// Find walks up from start, a file or directory, and returns the path of the first config file found.
+func Find(start string) (string, bool) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Load reads a config file, anything it leaves out keeps its default.
+func Load(path string) (Config, error) {
+	var f file
+	meta, err := toml.DecodeFile(path, &f)
+	if err != nil {
+		return Config{}, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("%s: unknown key `%s`", path, undecoded[0])
+	}
+
+	cfg := Default()
+	if f.Order != nil {
+		cfg.Order, err = parseOrder(f.Order)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	cfg.ReindentComments = f.ReindentComments
+	if f.LineLength != nil {
+		if *f.LineLength < 0 {
+			return Config{}, fmt.Errorf("%s: line_length can't be negative", path)
+		}
+		cfg.LineLength = f.LineLength
+	}
+	if f.MaxBlankLines != nil {
+		if *f.MaxBlankLines < 0 {
+			return Config{}, fmt.Errorf("%s: max_blank_lines can't be negative", path)
+		}
+		cfg.MaxBlankLines = f.MaxBlankLines
+	}
+
+	switch f.Regions {
+	case "":
+	case RegionsGroup, RegionsFixed:
+		cfg.Regions = f.Regions
+	default:
+		return Config{}, fmt.Errorf("%s: unknown regions mode `%s`, expected `%s` or `%s`", path, f.Regions, RegionsGroup, RegionsFixed)
+	}
+
+	return cfg, nil
+}
+
+// parseOrder turns block names into an order, types not listed keep their default order after the listed ones.
+func parseOrder(names []string) ([]tk.BlockType, error) {
+	var order []tk.BlockType
+	for _, name := range names {
+		bt, ok := tk.BlockTypeFromString(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown block type `%s` in order", name)
+		}
+		if slices.Contains(order, bt) {
+			return nil, fmt.Errorf("block type `%s` listed twice in order", name)
+		}
+		order = append(order, bt)
+	}
+	return CompleteOrder(order), nil
+}
+
+// ValidateOrder checks that an order only lists block types that can be ordered, each at most once.
+func ValidateOrder(order []tk.BlockType) error {
+	for i, bt := range order {
+		switch {
+		case bt < 0 || bt > tk.Positional:
+			return fmt.Errorf("unknown block type %d in order", bt)
+		case bt > tk.Unknown:
+			return fmt.Errorf("block type `%s` can't be ordered", tk.BlockTypeToString(bt))
+		case slices.Contains(order[:i], bt):
+			return fmt.Errorf("block type `%s` listed twice in order", tk.BlockTypeToString(bt))
+		}
+	}
+	return nil
+}
+
+// CompleteOrder returns the order with the types it doesn't list appended in their default order.
+func CompleteOrder(order []tk.BlockType) []tk.BlockType {
+	order = slices.Clone(order)
+	for _, bt := range Default().Order {
+		if !slices.Contains(order, bt) {
+			order = append(order, bt)
+		}
+	}
+	return order
+}
+
+// Ranks returns the position of every block type in the order, indexed by block type. EndComments always ranks last,
+// a Region ranks as its first member. Types that can't be ordered are skipped, see ValidateOrder.
+func (c Config) Ranks() []int {
+	ranks := make([]int, tk.Positional+1)
+	for i, bt := range c.Order {
+		if bt >= 0 && bt <= tk.Unknown {
+			ranks[bt] = i
+		}
+	}
+	ranks[tk.EndComments] = len(c.Order)
+	return ranks
+}
+
+// OrderNames returns the order as block type names, for printing.
+func (c Config) OrderNames() string {
+	var names []string
+	for _, bt := range c.Order {
+		names = append(names, tk.BlockTypeToString(bt))
+	}
+	return strings.Join(names, ", ")
+}
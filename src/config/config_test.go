@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tk "godot_linter/styler/tokendef"
+)
+
+func writeConfig(t *testing.T, dir string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), `order = ["onready", "Export", "local_var"]`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	expected := []tk.BlockType{tk.Onready, tk.Export, tk.LocalVar}
+	if !reflect.DeepEqual(cfg.Order[:3], expected) {
+		t.Errorf("configured types not first: %v", cfg.Order[:3])
+	}
+	if len(cfg.Order) != len(Default().Order) || cfg.Order[3] != tk.Tool {
+		t.Errorf("remaining types not in default order: %v", cfg.Order)
+	}
+
+	if cfg.Regions != RegionsGroup {
+		t.Errorf("regions mode not defaulted: %q", cfg.Regions)
+	}
+
+	ranks := cfg.Ranks()
+	if ranks[tk.Onready] >= ranks[tk.Export] {
+		t.Errorf("onready should rank before export: %v", ranks)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	off := 0
+	cfg := Config{Order: []tk.BlockType{tk.Extend}, LineLength: &off}.WithDefaults()
+
+	if len(cfg.Order) != len(Default().Order) || cfg.Order[0] != tk.Extend || cfg.Order[1] != tk.Tool {
+		t.Errorf("order not completed: %v", cfg.Order)
+	}
+	if cfg.Regions != RegionsGroup {
+		t.Errorf("regions mode not defaulted: %q", cfg.Regions)
+	}
+	if *cfg.LineLength != 0 {
+		t.Errorf("line length set to 0 changed to %d", *cfg.LineLength)
+	}
+	if *cfg.MaxBlankLines != 1 {
+		t.Errorf("max blank lines not defaulted: %d", *cfg.MaxBlankLines)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	if err := ValidateOrder(Default().Order); err != nil {
+		t.Errorf("default order rejected: %v", err)
+	}
+	for _, order := range [][]tk.BlockType{{40}, {tk.EndComments}, {tk.Region}, {tk.Tool, tk.Tool}} {
+		if err := ValidateOrder(order); err == nil {
+			t.Errorf("expected an error for order %v", order)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Unknown block type", `order = ["tool", "nope"]`},
+		{"Duplicate block type", `order = ["tool", "Tool"]`},
+		{"Unknown key", `ordre = ["tool"]`},
+		{"Invalid toml", `order = [`},
+		{"Unknown regions mode", `regions = "sorted"`},
+		{"Negative line length", `line_length = -1`},
+		{"Negative blank lines", `max_blank_lines = -1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.content)
+			if _, err := Load(path); err == nil {
+				t.Errorf("expected an error for %q", tt.content)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "scenes", "player")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(nested, "player.gd")
+	if err := os.WriteFile(script, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected := writeConfig(t, root, "")
+
+	for _, start := range []string{root, nested, script} {
+		path, found := Find(start)
+		if !found || path != expected {
+			t.Errorf("Find(%s) = %s, %v, expected %s", start, path, found, expected)
+		}
+	}
+}
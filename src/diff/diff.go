@@ -0,0 +1,222 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Op is what an Edit does to a line
+type Op int8
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of an edit script, Line keeps its trailing newline if it had one
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Context is how many unchanged lines surround each hunk, same as git diff
+const Context = 3
+
+// Unified returns the unified diff of a to b with git style headers and hunks,
+// or an empty string if they are equal.
+func Unified(oldName, newName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	edits := Lines(splitLines(a), splitLines(b))
+
+	// Line numbers before every edit
+	oldAt := make([]int, len(edits)+1)
+	newAt := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if e.Op != Insert {
+			oldAt[i+1]++
+		}
+		if e.Op != Delete {
+			newAt[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	i := 0
+	for i < len(edits) {
+		for i < len(edits) && edits[i].Op == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		start := max(i-Context, 0)
+		end := i
+		for {
+			for end < len(edits) && edits[end].Op != Equal {
+				end++
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == Equal {
+				run++
+			}
+			// Changes close enough to share context join the same hunk
+			if run == len(edits) || run-end > 2*Context {
+				end = min(end+Context, len(edits))
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(oldAt[start], oldAt[end]-oldAt[start]),
+			hunkRange(newAt[start], newAt[end]-newAt[start]),
+		)
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case Equal:
+				out.WriteString(" ")
+			case Delete:
+				out.WriteString("-")
+			case Insert:
+				out.WriteString("+")
+			}
+			out.WriteString(e.Line)
+			if !strings.HasSuffix(e.Line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+// hunkRange formats the start,count of a hunk header, start is the line before an empty range
+func hunkRange(before int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns a shortest edit script turning a into b, using Myers' algorithm.
+func Lines(a, b []string) []Edit {
+	// Common prefix and suffix need no search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+
+	// trace[d][k+d] is the furthest x reached on diagonal k with d edits
+	var trace [][]int32
+	for d := 0; d <= n+m; d++ {
+		next := make([]int32, 2*d+1)
+		var at func(k int) int32 // diagonal k in the previous round
+		if d > 0 {
+			prev := trace[d-1]
+			at = func(k int) int32 { return prev[k+d-1] }
+		}
+
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if d == 0 {
+				x = 0
+			} else if k == -d || k != d && at(k-1) < at(k+1) {
+				x = int(at(k + 1))
+			} else {
+				x = int(at(k-1)) + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			next[k+d] = int32(x)
+			if x >= n && y >= m {
+				done = true
+			}
+		}
+		trace = append(trace, next)
+		if done {
+			break
+		}
+	}
+
+	var edits []Edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int32 { return prev[k+d-1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := int(at(prevK))
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, Edit{Insert, b[y-1]})
+			y--
+		} else {
+			edits = append(edits, Edit{Delete, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, Edit{Equal, a[x-1]})
+		x--
+		y--
+	}
+
+	slices.Reverse(edits)
+	return edits
+}
@@ -0,0 +1,84 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name:     "Equal",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "Moved line",
+			a:    "extends Node\nclass_name Foo\n",
+			b:    "class_name Foo\nextends Node\n",
+			expected: "--- a/x.gd\n+++ b/x.gd\n@@ -1,2 +1,2 @@\n" +
+				"-extends Node\n class_name Foo\n+extends Node\n",
+		},
+		{
+			name: "Separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "1\nX\n3\n4\n5\n6\n7\n8\n9\n10\nY\n12\n",
+			expected: "--- a/x.gd\n+++ b/x.gd\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n 4\n 5\n" +
+				"@@ -8,5 +8,5 @@\n 8\n 9\n 10\n-11\n+Y\n 12\n",
+		},
+		{
+			name:     "No newline at end of file",
+			a:        "a\nb\n",
+			b:        "a\nb",
+			expected: "--- a/x.gd\n+++ b/x.gd\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name:     "From empty",
+			a:        "",
+			b:        "a\n",
+			expected: "--- a/x.gd\n+++ b/x.gd\n@@ -0,0 +1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Unified("a/x.gd", "b/x.gd", tt.a, tt.b)
+			if actual != tt.expected {
+				t.Errorf("Unified failed.\nExpected:\n%s\nGot:\n%s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLinesIsShortest(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+
+	edits := Lines(a, b)
+
+	changes := 0
+	var gotA, gotB []string
+	for _, e := range edits {
+		if e.Op != Insert {
+			gotA = append(gotA, e.Line)
+		}
+		if e.Op != Delete {
+			gotB = append(gotB, e.Line)
+		}
+		if e.Op != Equal {
+			changes++
+		}
+	}
+	if strings.Join(gotA, " ") != strings.Join(a, " ") || strings.Join(gotB, " ") != strings.Join(b, " ") {
+		t.Fatalf("edit script does not rebuild inputs: %v", edits)
+	}
+	if changes != 5 {
+		t.Errorf("expected 5 changes, got %d: %v", changes, edits)
+	}
+}
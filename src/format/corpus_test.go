@@ -0,0 +1,34 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCorpus formats every sample script in testdata/corpus, add scripts there to cover more cases
+func TestCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.gd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scripts in testdata/corpus")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Format(src, Options{}); err != nil {
+				t.Fatalf("Format returned error: %v", err)
+			}
+			if err := VerifyIdempotent(src, Options{}); err != nil {
+				t.Errorf("not idempotent: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package format formats GDScript source in memory, it never prints or touches the filesystem.
+package format
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"godot_linter/config"
+	"godot_linter/styler/block_stylers"
+	"godot_linter/styler/lexer"
+	"godot_linter/styler/migrate"
+	tk "godot_linter/styler/tokendef"
+	"godot_linter/styler/tokeniser"
+)
+
+// Stages passed to Options.Trace
+const (
+	StageTokenised = "tokenised"
+	StageSorted    = "sorted"
+)
+
+type Options struct {
+	// Config to format with, anything it leaves unset uses the default, see config.Config.WithDefaults
+	Config config.Config
+	// Trace is called with the blocks after each stage when set, for debugging
+	Trace func(stage string, blocks []tk.Block)
+	// Rewrite Godot 3 syntax to Godot 4 before formatting
+	MigrateGodot3 bool
+}
+
+// ConfigError is returned when Options.Config can't be formatted with
+type ConfigError struct {
+	Message string
+}
+
+func (cerr *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config: %s", cerr.Message)
+}
+
+// LineError points at a line of the source that couldn't be tokenised, Line and Col are 1-based
+type LineError struct {
+	Line    int
+	Col     int
+	Text    string
+	Message string
+}
+
+// TokenizeError is returned when the source can't be split into blocks
+type TokenizeError struct {
+	Message string
+	Lines   []LineError
+}
+
+func (terr *TokenizeError) Error() string {
+	return fmt.Sprintf("tokenising failed: %s", terr.Message)
+}
+
+func newTokenizeError(err error, lines []string) *TokenizeError {
+	terr := &TokenizeError{Message: err.Error()}
+
+	var uerr *tokeniser.UnknownError
+	var lerr lexer.ErrorList
+	switch {
+	case errors.As(err, &uerr):
+		terr.Message = "Unknown component in script"
+		for _, l := range uerr.Lines {
+			terr.Lines = append(terr.Lines, LineError{Line: l.Line, Col: l.Col, Text: l.Text, Message: "unknown component"})
+		}
+	case errors.As(err, &lerr):
+		for _, l := range lerr {
+			text := ""
+			if l.Line >= 1 && l.Line <= len(lines) {
+				text = strings.TrimSpace(lines[l.Line-1])
+			}
+			terr.Lines = append(terr.Lines, LineError{Line: l.Line, Col: l.Col, Text: text, Message: l.Msg})
+		}
+	}
+	return terr
+}
+
+// Format returns the formatted source, or a VerifyError if the result wouldn't be a reordering of src
+func Format(src []byte, opts Options) ([]byte, error) {
+	if err := config.ValidateOrder(opts.Config.Order); err != nil {
+		return nil, &ConfigError{Message: err.Error()}
+	}
+	cfg := opts.Config.WithDefaults()
+
+	if opts.MigrateGodot3 {
+		// The rewrite is the new source the result is verified against
+		src = []byte(migrate.Godot3(string(src)))
+	}
+
+	lines := strings.Split(string(src), "\n")
+	if cfg.ReindentComments {
+		lines = tokeniser.ReindentComments(lines)
+	}
+
+	blocks, err := tokeniser.Tokenize(lines)
+	if err != nil {
+		return nil, newTokenizeError(err, lines)
+	}
+	trace(opts, StageTokenised, blocks)
+
+	SortBlocks(blocks, cfg)
+	trace(opts, StageSorted, blocks)
+
+	StyleBlocks(blocks, cfg)
+
+	out := []byte(Detokenise(blocks))
+	if strings.HasSuffix(string(src), "\n") {
+		// Keep the final newline editors and git expect
+		out = append(out, '\n')
+	}
+
+	// Never hand out a result that lost or merged members
+	if err := Verify(src, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func trace(opts Options, stage string, blocks []tk.Block) {
+	if opts.Trace != nil {
+		opts.Trace(stage, blocks)
+	}
+}
+
+// SortBlocks sorts blocks by the configured type order then their order within the type,
+// and the members of every inner class and region the same way. Anything cfg leaves unset uses the default.
+func SortBlocks(tokens []tk.Block, cfg config.Config) {
+	cfg = cfg.WithDefaults()
+	sortBlocks(tokens, cfg.Ranks(), cfg.Regions == config.RegionsFixed)
+}
+
+func sortBlocks(tokens []tk.Block, ranks []int, fixedRegions bool) {
+	// Members first, a region ranks as its first member
+	for _, t := range tokens {
+		sortBlocks(t.Children, ranks, fixedRegions)
+	}
+
+	// Sort the runs between pinned blocks, leaving those in place
+	start := 0
+	for i, t := range tokens {
+		if pinned(t, fixedRegions) {
+			sortRun(tokens[start:i], ranks)
+			start = i + 1
+		}
+	}
+	sortRun(tokens[start:], ranks)
+}
+
+// pinned reports whether a block keeps its place: positional lines, and regions when they are fixed or
+// hold a positional line, as moving them would move members in or out of its range
+func pinned(block tk.Block, fixedRegions bool) bool {
+	switch block.Type {
+	case tk.Positional:
+		return true
+	case tk.Region:
+		return fixedRegions || slices.ContainsFunc(block.Children, func(child tk.Block) bool {
+			return pinned(child, false)
+		})
+	}
+	return false
+}
+
+func sortRun(tokens []tk.Block, ranks []int) {
+	slices.SortStableFunc(tokens, func(a, b tk.Block) int {
+		if r := rank(a, ranks) - rank(b, ranks); r != 0 {
+			return r
+		}
+		if a.Type != b.Type {
+			return 0
+		}
+		return a.Order - b.Order
+	})
+}
+
+// rank returns the position of a block's type in the order, a region without members ranks last
+func rank(block tk.Block, ranks []int) int {
+	if block.Type != tk.Region {
+		return ranks[block.Type]
+	}
+	if len(block.Children) == 0 {
+		return ranks[tk.EndComments]
+	}
+	return rank(block.Children[0], ranks)
+}
+
+// StyleBlocks normalises the spacing within the lines of blocks and their members, breaks lines longer than
+// the configured length at their brackets and normalises their blank lines and trailing whitespace.
+// Properties are found again in the restyled lines. Anything cfg leaves unset uses the default.
+func StyleBlocks(tokens []tk.Block, cfg config.Config) {
+	cfg = cfg.WithDefaults()
+	styleBlocks(tokens, *cfg.LineLength, *cfg.MaxBlankLines)
+}
+
+func styleBlocks(tokens []tk.Block, lineLength int, maxBlankLines int) {
+	for i := range tokens {
+		t := &tokens[i]
+		t.Content = block_stylers.StyleSpacing(t.Content)
+		t.Content = block_stylers.Wrap(t.Content, lineLength)
+		t.Content = block_stylers.StyleBlankLines(t.Content, maxBlankLines)
+		if len(t.End) > 0 {
+			t.End = block_stylers.StyleBlankLines(block_stylers.StyleSpacing(t.End), maxBlankLines)
+		}
+		if len(t.Properties) > 0 {
+			t.Properties = tokeniser.Properties(t.Content)
+		}
+
+		members := lineLength
+		if t.Type == tk.Class && lineLength > 0 {
+			// Members are indented in the class
+			members = max(lineLength-lexer.IndentWidth, 1)
+		}
+		styleBlocks(t.Children, members, maxBlankLines)
+	}
+}
+
+func Detokenise(tokens []tk.Block) string {
+	file := ""
+	for i, token := range tokens {
+		file += strings.Join(token.Content, "\n")
+
+		switch {
+		case token.Type == tk.Region && len(token.Children) > 0:
+			file += "\n" + Detokenise(token.Children)
+		case len(token.Children) > 0:
+			file += "\n" + indentLines(Detokenise(token.Children))
+		}
+		if len(token.End) > 0 {
+			file += "\n" + strings.Join(token.End, "\n")
+		}
+
+		if i+1 == len(tokens) {
+			break
+		}
+
+		// Start with 1 newline
+		newlines := 2
+
+		last := token.Type
+		if last == tk.Region && len(token.Children) > 0 {
+			// Spaced as the member it ends with
+			last = token.Children[len(token.Children)-1].Type
+		}
+
+		switch last {
+		case tk.ClassName, tk.ClassAnnotation, tk.Positional:
+			newlines--
+		case tk.Tool, tk.Extend:
+			// Class annotations are kept together, the class documentation directly below extends
+			if next := tokens[i+1].Type; next == tk.ClassAnnotation || next == tk.ClassDoc {
+				newlines--
+			}
+		case tk.StaticFunction, tk.Virtual, tk.Function, tk.PrivateFunction, tk.Class:
+			newlines++
+		}
+
+		file += strings.Repeat("\n", newlines)
+	}
+
+	return file
+}
+
+// indentLines indents every non blank line of text by one tab, lines inside multi-line strings are kept as is.
+func indentLines(text string) string {
+	lines := strings.Split(text, "\n")
+	tokens, _ := lexer.Lex(text)
+	inString := lexer.StringLines(tokens, len(lines))
+	for i, line := range lines {
+		if line != "" && !inString[i] {
+			lines[i] = "\t" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
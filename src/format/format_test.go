@@ -0,0 +1,163 @@
+package format
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"godot_linter/config"
+	tk "godot_linter/styler/tokendef"
+	"godot_linter/styler/tokeniser"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "Default order without a config",
+			input:    "func f():\n\tpass\nvar a\nextends Node",
+			expected: "extends Node\n\nvar a\n\nfunc f():\n\tpass",
+		},
+		{
+			name:     "Configured order",
+			input:    "extends Node\nconst A = 1\nsignal s",
+			opts:     Options{Config: config.Config{Order: []tk.BlockType{tk.Constants, tk.Signals, tk.Extend}}},
+			expected: "const A = 1\n\nsignal s\n\nextends Node",
+		},
+		{
+			name:     "Partial order",
+			input:    "func f():\n\tpass\nvar a\nconst C = 1\nextends Node",
+			opts:     Options{Config: config.Config{Order: []tk.BlockType{tk.Extend, tk.Constants}}},
+			expected: "extends Node\n\nconst C = 1\n\nvar a\n\nfunc f():\n\tpass",
+		},
+		{
+			name:     "Godot 3 migration",
+			input:    "func _ready():\n\tyield(owner, \"ready\")\nonready var a = $A\ntool",
+			opts:     Options{MigrateGodot3: true},
+			expected: "@tool\n\n@onready var a = $A\n\nfunc _ready():\n\tawait owner.ready",
+		},
+		{
+			name:     "Inner class members",
+			input:    "class A:\n\tfunc f():\n\t\tpass\n\tvar x",
+			expected: "class A:\n\tvar x\n\n\tfunc f():\n\t\tpass",
+		},
+		{
+			name:     "Final newline kept",
+			input:    "var a = 1\nextends Node\n",
+			expected: "extends Node\n\nvar a = 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Format([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("Format returned error: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Format failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, string(out))
+			}
+		})
+	}
+}
+
+// TestFormatConfigDefaults checks a config that only sets the order keeps the default spacing, wrapping and regions mode
+func TestFormatConfigDefaults(t *testing.T) {
+	input := "func f():\n\tvar a = 1\n\n\tvar b = [" + strings.Repeat("1, ", 40) + "1]\n#region R\nvar c\n#endregion\nextends Node"
+	opts := Options{Config: config.Config{Order: []tk.BlockType{tk.Extend}}}
+
+	out, err := Format([]byte(input), opts)
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	expected, err := Format([]byte(input), Options{Config: config.Default()})
+	if err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if string(out) != string(expected) {
+		t.Errorf("unset fields not defaulted.\nExpected:\n%s\nGot:\n%s", expected, out)
+	}
+}
+
+func TestStyleBlocksProperties(t *testing.T) {
+	input := "var values = [first_value, second_value]:\n\tget:\n\t\treturn values\nvar speed = 1"
+	blocks, err := tokeniser.Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	width := 30
+	StyleBlocks(blocks, config.Config{LineLength: &width})
+
+	expected := []tk.Property{{Start: 0, End: 5}}
+	if !reflect.DeepEqual(blocks[0].Properties, expected) {
+		t.Errorf("properties not found again after wrapping.\nExpected:\n%v\nGot:\n%v\nIn:\n%s", expected, blocks[0].Properties, strings.Join(blocks[0].Content, "\n"))
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := Format([]byte("extends Node\nprint('top level')"), Options{})
+
+	var terr *TokenizeError
+	if !errors.As(err, &terr) {
+		t.Fatalf("expected a TokenizeError, got %v", err)
+	}
+}
+
+func TestFormatErrorColumns(t *testing.T) {
+	input := "extends Node\n\nfunc f():\n    var s = \"abc\n    foo(]"
+	_, err := Format([]byte(input), Options{})
+
+	var terr *TokenizeError
+	if !errors.As(err, &terr) {
+		t.Fatalf("expected a TokenizeError, got %v", err)
+	}
+
+	var cols []int
+	for _, l := range terr.Lines {
+		cols = append(cols, l.Col)
+	}
+	if !slices.Equal(cols, []int{13, 9}) {
+		t.Errorf("columns should point into the space indented lines, got %v", terr.Lines)
+	}
+}
+
+func TestFormatConfigError(t *testing.T) {
+	orders := [][]tk.BlockType{
+		{40},
+		{-1},
+		{tk.Extend, tk.EndComments},
+		{tk.Region},
+		{tk.Positional},
+		{tk.Extend, tk.Signals, tk.Extend},
+	}
+
+	for _, order := range orders {
+		_, err := Format([]byte("extends Node"), Options{Config: config.Config{Order: order}})
+
+		var cerr *ConfigError
+		if !errors.As(err, &cerr) {
+			t.Errorf("expected a ConfigError for order %v, got %v", order, err)
+		}
+	}
+}
+
+func TestFormatTrace(t *testing.T) {
+	var stages []string
+	opts := Options{Trace: func(stage string, blocks []tk.Block) {
+		stages = append(stages, stage)
+	}}
+
+	if _, err := Format([]byte("var a"), opts); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if len(stages) != 2 || stages[0] != StageTokenised || stages[1] != StageSorted {
+		t.Errorf("unexpected trace stages %v", stages)
+	}
+}
@@ -0,0 +1,72 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"godot_linter/config"
+)
+
+// FuzzFormat checks the pipeline never panics, and when it succeeds keeps every non blank line,
+// ignoring the spacing within it, and gives the same result on a second pass with and without wrapping.
+// The sample scripts in testdata seed the corpus.
+func FuzzFormat(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("testdata", "*", "*.gd"))
+	more, _ := filepath.Glob(filepath.Join("testdata", "*", "*", "input.gd"))
+	for _, path := range append(paths, more...) {
+		if src, err := os.ReadFile(path); err == nil {
+			f.Add(src)
+		}
+	}
+
+	// Wrapping splits lines, compare the lines without it
+	off := 0
+	unwrapped := Options{Config: config.Config{LineLength: &off}}
+
+	f.Fuzz(func(t *testing.T, src []byte) {
+		out, err := Format(src, unwrapped)
+		if err != nil {
+			return
+		}
+
+		counts := map[string]int{}
+		for _, line := range strings.Split(string(src), "\n") {
+			if line := stripSpace(line); line != "" {
+				counts[line]++
+			}
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line := stripSpace(line); line != "" {
+				counts[line]--
+			}
+		}
+
+		for line, n := range counts {
+			if n > 0 {
+				t.Fatalf("line lost: %q", line)
+			} else if n < 0 {
+				t.Fatalf("line duplicated: %q", line)
+			}
+		}
+
+		for _, opts := range []Options{unwrapped, {}} {
+			first, err := Format(src, opts)
+			if err != nil {
+				t.Fatalf("formatting fails with line length %d: %v", *opts.Config.WithDefaults().LineLength, err)
+			}
+			second, err := Format(first, opts)
+			if err != nil {
+				t.Fatalf("formatted output fails: %v", err)
+			}
+			if string(second) != string(first) {
+				t.Fatalf("not idempotent:\n%s\n---\n%s", first, second)
+			}
+		}
+	})
+}
+
+func stripSpace(line string) string {
+	return strings.Join(strings.Fields(line), "")
+}
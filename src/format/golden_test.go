@@ -0,0 +1,88 @@
+package format
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"godot_linter/config"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected files of the golden tests")
+
+// TestGolden formats input.gd of every case in testdata/golden and compares it to expected.gd,
+// or to expected.err for inputs that fail. A case can have its own config file.
+// Run with -update to regenerate the expected files after a deliberate change.
+func TestGolden(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no cases in testdata/golden")
+	}
+
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join(dir, "input.gd"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := Options{}
+			if path := filepath.Join(dir, config.FileName); exists(path) {
+				opts.Config, err = config.Load(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			name, got := "expected.gd", ""
+			out, err := Format(src, opts)
+			if err != nil {
+				name, got = "expected.err", errorText(err)
+			} else {
+				got = string(out)
+			}
+
+			expectedPath := filepath.Join(dir, name)
+			if *update {
+				os.Remove(filepath.Join(dir, "expected.gd"))
+				os.Remove(filepath.Join(dir, "expected.err"))
+				if err := os.WriteFile(expectedPath, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("missing %s, run the tests with -update to create it: %v", name, err)
+			}
+			if got != string(expected) {
+				t.Errorf("%s doesn't match.\nExpected:\n%s\nGot:\n%s", name, expected, got)
+			}
+		})
+	}
+}
+
+// errorText is the error with every line it points at
+func errorText(err error) string {
+	text := err.Error() + "\n"
+
+	var terr *TokenizeError
+	if errors.As(err, &terr) {
+		for _, l := range terr.Lines {
+			text += fmt.Sprintf("%d:%d: %s: %s\n", l.Line, l.Col, l.Message, l.Text)
+		}
+	}
+	return text
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,49 @@
+@tool
+extends Resource
+class_name Inventory
+
+"""
+Holds items, slots are fixed on creation.
+"""
+
+func add(item: Item) -> bool:
+	for i in slots.size():
+		if slots[i] == null:
+			slots[i] = item
+			changed.emit()
+			return true
+	return false
+
+var slots: Array[Item] = []
+
+# Emitted when any slot changes
+signal changed
+
+enum Rarity {
+	COMMON,
+	RARE, # drops less often
+	LEGENDARY,
+}
+
+func _init(size: int = 8) -> void:
+	slots.resize(size)
+
+const MAX_STACK = 99
+const DEFAULT_ITEMS = [
+	"potion",
+	"key",
+]
+
+
+func _to_string() -> String:
+	return "Inventory(%d)" % slots.size()
+
+class Item:
+	var name := ""
+	var count := 1
+
+	func _init(item_name: String) -> void:
+		name = item_name
+
+	func stack(other: Item) -> void:
+		count = min(count + other.count, MAX_STACK)
@@ -0,0 +1,72 @@
+class_name Player
+extends CharacterBody2D
+## Player controller, reads input and moves the body.
+
+signal health_changed(old_value: int, new_value: int)
+signal died
+
+enum State { IDLE, RUN, JUMP, FALL }
+
+const SPEED := 300.0
+const JUMP_VELOCITY = -400.0
+const ANIMATIONS = {
+	"idle": "player_idle",
+	"run": "player_run", # looped
+	"jump": "player_jump",
+}
+
+@export var max_health: int = 100
+@export_range(0.0, 1.0, 0.05) var friction := 0.2
+
+@onready var sprite: AnimatedSprite2D = $AnimatedSprite2D
+@onready var hitbox := %Hitbox as Area2D
+
+static var instances := 0
+
+var state: State = State.IDLE
+var health := max_health
+var _last_hit_by = null # set by take_damage
+
+
+func _physics_process(delta: float) -> void:
+	if not is_on_floor():
+		velocity += get_gravity() * delta
+
+	# Jumping
+	if Input.is_action_just_pressed("ui_accept") and is_on_floor():
+		velocity.y = JUMP_VELOCITY
+
+	var direction := Input.get_axis("ui_left", "ui_right")
+	if direction:
+		velocity.x = direction * SPEED
+	else:
+		velocity.x = move_toward(velocity.x, 0, SPEED)
+
+	move_and_slide()
+
+
+func _ready() -> void:
+	instances += 1
+	sprite.play(ANIMATIONS["idle"])
+
+
+func take_damage(amount: int, source: Node = null) -> void:
+	var old := health
+	health = max(health - amount, 0)
+	_last_hit_by = source
+	health_changed.emit(old, health)
+	if health == 0:
+		_die()
+
+
+static func count() -> int:
+	return instances
+
+
+func _die() -> void:
+	var message = "player # %d died" % get_instance_id()
+	print(message)
+	died.emit()
+	queue_free()
+
+# TODO: respawn
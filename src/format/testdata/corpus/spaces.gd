@@ -0,0 +1,13 @@
+extends Node2D
+
+func _process(delta):
+    rotation += delta
+    if rotation > TAU:
+        rotation -= TAU
+
+var speed = 1.0
+
+func _enter_tree():
+    print("entered")
+
+@export var color := Color.RED
@@ -0,0 +1,16 @@
+extends Node
+
+class State extends RefCounted:
+	func enter():
+		pass
+	var name = ""
+	const ID = 1
+	class Sub:
+		func f():
+			pass
+		signal done
+	signal changed
+
+func _ready():
+	pass
+var a = 1
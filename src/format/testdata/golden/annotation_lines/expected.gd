@@ -0,0 +1,23 @@
+extends Node
+
+@export
+var a: int = 1
+
+@export_range(0, 10) var d = 3
+
+@warning_ignore("unused_private_class_variable")
+@export_storage
+var _state := {}
+
+@export_group("Stats")
+@export var health := 10
+
+# Cached on ready
+@onready
+var sprite = $Sprite
+
+var speed = 1
+
+@rpc("any_peer")
+func hit():
+	pass
@@ -0,0 +1,23 @@
+extends Node
+
+var speed = 1
+
+@export
+var a: int = 1
+
+@export_range(0, 10) var d = 3
+
+# Cached on ready
+@onready
+var sprite = $Sprite
+
+@warning_ignore("unused_private_class_variable")
+@export_storage
+var _state := {}
+
+@export_group("Stats")
+@export var health := 10
+
+@rpc("any_peer")
+func hit():
+	pass
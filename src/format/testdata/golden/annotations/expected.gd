@@ -0,0 +1,25 @@
+@tool
+@static_unload
+@icon("res://player.svg")
+class_name Player
+extends Node
+
+@export_group("Movement")
+@export var speed := 10.0
+
+# Node references
+@onready var sprite = $Sprite
+
+@warning_ignore("unused_private_class_variable") var _cache = {}
+
+@rpc("any_peer", "call_local")
+func jump():
+	pass
+
+
+@abstract func attack()
+
+
+@warning_ignore("unused_parameter")
+func _helper(delta):
+	pass
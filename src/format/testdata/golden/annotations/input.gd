@@ -0,0 +1,23 @@
+extends Node
+class_name Player
+@static_unload
+@icon("res://player.svg")
+@tool
+
+@warning_ignore("unused_parameter")
+func _helper(delta):
+	pass
+
+@rpc("any_peer", "call_local")
+func jump():
+	pass
+
+@warning_ignore("unused_private_class_variable") var _cache = {}
+
+# Node references
+@onready var sprite = $Sprite
+
+@abstract func attack()
+
+@export_group("Movement")
+@export var speed := 10.0
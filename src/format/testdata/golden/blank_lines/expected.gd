@@ -0,0 +1,21 @@
+extends Node
+
+var label_text = """Line one   
+
+
+
+Line two"""
+
+func _process(delta):
+	var speed = 10
+
+	position.x += speed * delta
+	if position.x > 100:
+		position.x = 0
+	# wrap around
+	queue_redraw()
+
+
+# about _draw
+func _draw():
+	draw_circle(Vector2.ZERO, 4, Color.RED)
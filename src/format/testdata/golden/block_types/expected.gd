@@ -0,0 +1,35 @@
+@tool
+
+class_name Example
+extends Node
+
+"""
+Script docs.
+"""
+
+signal changed
+
+enum Mode {A, B}
+
+const MAX = 5
+
+@export var health = 3
+
+@onready var label = $Label
+
+class Data:
+	var value = 0
+
+
+var speed = 10
+
+func _ready():
+	run()
+
+
+func run():
+	_helper()
+
+
+func _helper():
+	pass
@@ -0,0 +1,33 @@
+func _helper():
+	pass
+
+func run():
+	_helper()
+
+func _ready():
+	run()
+
+var speed = 10
+
+class Data:
+	var value = 0
+
+@onready var label = $Label
+
+@export var health = 3
+
+const MAX = 5
+
+enum Mode { A, B }
+
+signal changed
+
+"""
+Script docs.
+"""
+
+extends Node
+
+class_name Example
+
+@tool
@@ -0,0 +1,14 @@
+class_name Player
+extends CharacterBody2D
+## The player character.
+##
+## Handles movement and health.
+
+## Emitted when the player dies.
+signal died
+
+## Movement speed in pixels per second.
+@export var speed := 200.0
+
+func _ready():
+	pass
@@ -0,0 +1,14 @@
+## The player character.
+##
+## Handles movement and health.
+class_name Player
+extends CharacterBody2D
+
+func _ready():
+	pass
+
+## Movement speed in pixels per second.
+@export var speed := 200.0
+
+## Emitted when the player dies.
+signal died
@@ -0,0 +1,13 @@
+# Script header comment
+extends Node
+
+# Linked to a
+# over two lines
+var a = 1 # trailing a
+
+func b():
+	# inside b
+	pass
+
+
+# End of file comment
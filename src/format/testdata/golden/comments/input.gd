@@ -0,0 +1,13 @@
+# Script header comment
+extends Node
+
+func b():
+	# inside b
+	pass
+
+# Linked to a
+# over two lines
+
+var a = 1 # trailing a
+
+# End of file comment
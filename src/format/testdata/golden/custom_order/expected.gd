@@ -0,0 +1,11 @@
+func f():
+	pass
+
+
+var v
+
+extends Node
+
+signal s
+
+const C = 1
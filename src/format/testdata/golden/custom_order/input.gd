@@ -0,0 +1,10 @@
+extends Node
+
+signal s
+
+const C = 1
+
+var v
+
+func f():
+	pass
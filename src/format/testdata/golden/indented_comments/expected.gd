@@ -0,0 +1,11 @@
+extends Node
+
+signal done
+	# about done
+
+# about a
+	# more about a
+var a
+
+func f():
+	pass
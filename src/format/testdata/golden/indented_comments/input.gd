@@ -0,0 +1,10 @@
+extends Node
+signal done
+	# about done
+
+func f():
+	pass
+
+# about a
+	# more about a
+var a
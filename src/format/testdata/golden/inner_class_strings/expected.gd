@@ -0,0 +1,10 @@
+extends Node
+
+class Help:
+	var a = 1
+
+	func text():
+		return """
+line at column 0
+	line with a tab
+"""
@@ -0,0 +1,9 @@
+extends Node
+
+class Help:
+	func text():
+		return """
+line at column 0
+	line with a tab
+"""
+	var a = 1
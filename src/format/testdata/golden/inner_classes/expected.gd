@@ -0,0 +1,24 @@
+extends Node
+
+class State extends RefCounted:
+	signal changed
+
+	const ID = 1
+
+	class Sub:
+		signal done
+
+		func f():
+			pass
+
+
+	var name = ""
+
+	func enter():
+		pass
+
+
+var a = 1
+
+func _ready():
+	pass
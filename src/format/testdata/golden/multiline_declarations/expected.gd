@@ -0,0 +1,49 @@
+extends Node
+
+signal hit(
+damage: int,
+source: Node
+)
+
+enum State {
+IDLE,
+RUN,
+}
+
+const TABLE = {
+"a": [
+1, 2,
+],
+"b": 3,
+}
+
+class Inner:
+	signal s(
+	a)
+
+	const D = {
+	"k": 1,
+	}
+
+	var x = 1 + \
+	2
+
+
+var total = 1 + \
+2 + \
+3
+
+var dictionary = {
+"""key""": 0
+}
+
+var arr = [
+# comment in array
+1,
+]
+
+var y
+
+func f(a,
+b):
+	pass
@@ -0,0 +1,38 @@
+extends Node
+
+const TABLE = {
+"a": [
+1, 2,
+],
+"b": 3,
+}
+var total = 1 + \
+2 + \
+3
+signal hit(
+damage: int,
+source: Node
+)
+enum State {
+IDLE,
+RUN,
+}
+var dictionary = {
+"""key""" : 0
+}
+func f(a,
+b):
+	pass
+var arr = [
+# comment in array
+1,
+]
+class Inner:
+	const D = {
+"k": 1,
+	}
+	signal s(
+a)
+	var x = 1 + \
+2
+var y
@@ -0,0 +1,25 @@
+extends Node
+
+signal health_changed(value)
+
+static var count := 0:
+	get:
+		return count
+
+@export var speed: float:
+	set = set_speed, get = get_speed
+
+@onready var label: Label = $Label:
+	set(value):
+		label = value
+
+# Current health
+var health: int = 100:
+	set(value):
+		health = clamp(value, 0, 100)
+		health_changed.emit(health)
+	get:
+		return health
+
+func _ready():
+	pass
@@ -0,0 +1,25 @@
+extends Node
+
+func _ready():
+	pass
+
+static var count := 0:
+	get:
+		return count
+
+@onready var label: Label = $Label:
+	set(value):
+		label = value
+
+# Current health
+var health: int = 100:
+	set(value):
+		health = clamp(value, 0, 100)
+		health_changed.emit(health)
+	get:
+		return health
+
+@export var speed: float:
+	set = set_speed, get = get_speed
+
+signal health_changed(value)
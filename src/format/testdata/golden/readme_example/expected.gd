@@ -0,0 +1,31 @@
+class_name enemy
+extends Node3D
+
+# Attack range
+# Yeah thats the attack range
+@export var range: int = 0
+
+@export var dmg: int = 0
+
+@export var speed: int = 0
+@export var also_speed: int = 0
+
+# Fruits block
+@onready var fruit0 = "apple"
+@onready var fruit1 = "banana"
+@onready var fruit2 = "cherry"
+@onready var fruit3 = "date"
+
+# Move
+func _process(delta: float) -> void:
+	position.x += 1
+	var foo = "bar"
+	print('banana')
+
+
+func foo(delta: float) -> void:
+	position.y += 1
+
+
+func bar(delta: float) -> void:
+	position.z -= 1
@@ -0,0 +1,27 @@
+extends Node
+
+signal died
+
+#region Health
+#region Signals
+signal hit
+#endregion
+
+var health = 100
+#endregion
+
+#region Movement
+# Pixels per second
+const MAX_SPEED = 100
+
+var speed = 10
+
+func move():
+	pass
+#endregion
+
+
+var name = "player"
+
+func _ready():
+	pass
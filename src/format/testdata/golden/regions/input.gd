@@ -0,0 +1,26 @@
+extends Node
+
+func _ready():
+	pass
+
+#region Movement
+func move():
+	pass
+
+var speed = 10
+
+# Pixels per second
+const MAX_SPEED = 100
+#endregion
+
+signal died
+
+#region Health
+var health = 100
+
+#region Signals
+signal hit
+#endregion
+#endregion
+
+var name = "player"
@@ -0,0 +1,28 @@
+extends Node
+
+func _ready():
+	pass
+
+
+#region Movement
+# Pixels per second
+const MAX_SPEED = 100
+
+var speed = 10
+
+func move():
+	pass
+#endregion
+
+
+signal died
+
+#region Health
+var health = 100
+
+#region Signals
+signal hit
+#endregion
+#endregion
+
+var name = "player"
@@ -0,0 +1,7 @@
+extends Node
+
+var x = 1
+
+func f():
+	if true:
+		print("four spaces")
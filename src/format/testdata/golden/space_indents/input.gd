@@ -0,0 +1,7 @@
+extends Node
+
+func f():
+    if true:
+        print("four spaces")
+
+var x = 1
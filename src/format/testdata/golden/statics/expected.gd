@@ -0,0 +1,20 @@
+extends Node
+
+static var count = 0
+
+static var instances: Array[Node] = []
+
+@export var speed := 1.0
+
+var a = 1
+
+static func _static_init() -> void:
+	count = 1
+
+
+static func bar():
+	pass
+
+
+func _ready():
+	pass
@@ -0,0 +1,18 @@
+extends Node
+
+static func bar():
+	pass
+
+static var count = 0
+
+func _ready():
+	pass
+
+var a = 1
+
+static  var  instances: Array[Node] = []
+
+static func _static_init() -> void:
+	count = 1
+
+@export var speed := 1.0
@@ -0,0 +1,19 @@
+extends Node
+
+class Inner:
+	# between members
+	var b
+
+	func bar():
+		pass
+
+
+func foo():
+	var a = 1
+# unindented comment
+	return a
+
+
+# about baz
+func baz():
+	pass
@@ -0,0 +1,16 @@
+extends Node
+
+func foo():
+	var a = 1
+# unindented comment
+	return a
+
+class Inner:
+	func bar():
+		pass
+# between members
+	var b
+
+# about baz
+func baz():
+	pass
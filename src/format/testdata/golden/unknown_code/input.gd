@@ -0,0 +1,6 @@
+extends Node
+
+print("top level code")
+
+func f():
+	pass
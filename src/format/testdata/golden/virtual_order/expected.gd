@@ -0,0 +1,32 @@
+extends Node
+
+func _init():
+	pass
+
+
+func _enter_tree():
+	pass
+
+
+func _ready():
+	pass
+
+
+func _process(delta):
+	pass
+
+
+func _physics_process(delta):
+	pass
+
+
+func _exit_tree():
+	pass
+
+
+func _input(event):
+	pass
+
+
+func _to_string():
+	return "node"
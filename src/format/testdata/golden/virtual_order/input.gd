@@ -0,0 +1,25 @@
+extends Node
+
+func _to_string():
+	return "node"
+
+func _input(event):
+	pass
+
+func _process(delta):
+	pass
+
+func _physics_process(delta):
+	pass
+
+func _ready():
+	pass
+
+func _exit_tree():
+	pass
+
+func _enter_tree():
+	pass
+
+func _init():
+	pass
@@ -0,0 +1,35 @@
+extends Node
+
+const C = 1
+
+@warning_ignore_start("unused_variable", "unused_parameter")
+signal s
+
+var a = 2
+
+func b(x):
+	var unused = 1
+
+
+@warning_ignore_restore("unused_variable", "unused_parameter")
+var d = 4
+
+func c():
+	pass
+
+
+#region Helpers
+func h():
+	pass
+
+
+@warning_ignore_start("unused_variable")
+var r = 1
+#endregion
+
+func e():
+	var unused = 5
+
+
+@warning_ignore_restore("unused_variable")
+const F = 6
@@ -0,0 +1,31 @@
+extends Node
+
+const C = 1
+
+@warning_ignore_start("unused_variable", "unused_parameter")
+func b(x):
+	var unused = 1
+
+var a = 2
+
+signal s
+@warning_ignore_restore("unused_variable", "unused_parameter")
+
+func c():
+	pass
+
+var d = 4
+
+#region Helpers
+func h():
+	pass
+
+@warning_ignore_start("unused_variable")
+var r = 1
+#endregion
+
+func e():
+	var unused = 5
+@warning_ignore_restore("unused_variable")
+
+const F = 6
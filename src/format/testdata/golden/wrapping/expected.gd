@@ -0,0 +1,39 @@
+extends Node
+
+const ITEMS = {
+	"sword": {"damage": 10, "weight": 5, "price": 100},
+	"shield": {"defense": 8, "weight": 7},
+}
+
+@export_enum(
+	"Warrior",
+	"Magician",
+	"Thief",
+	"Archer",
+	"Necromancer",
+	"Paladin",
+	"Druid",
+) var character_class: String
+
+class Inner:
+	func long_only_when_nested_inside_of_the_class(
+		first_argument,
+		second_argument,
+		third_argument_x,
+	):
+		pass
+
+
+func spawn_enemy(
+	enemy_type: String,
+	position: Vector2,
+	health: int = 100,
+	speed: float = 1.5,
+) -> Node2D:
+	var enemy = preload("res://enemies/a_very_long_path/to_some_enemy_scene_that_is_long/enemy.tscn").instantiate()
+	get_tree().current_scene.get_node("Enemies").add_child(
+		enemy,
+		true,
+		Node.INTERNAL_MODE_DISABLED,
+	) # add it
+	return enemy
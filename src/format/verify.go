@@ -0,0 +1,212 @@
+package format
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"godot_linter/styler/lexer"
+	tk "godot_linter/styler/tokendef"
+	"godot_linter/styler/tokeniser"
+)
+
+// VerifyError is returned when formatted source isn't a reordering of the original
+type VerifyError struct {
+	Message string
+}
+
+func (verr *VerifyError) Error() string {
+	return fmt.Sprintf("output doesn't match the input: %s", verr.Message)
+}
+
+// Verify checks that after holds the same members as before, only reordered.
+// Every member must keep the same lines and comments, nothing may be lost, duplicated or moved between members,
+// and accessors not between the properties of a member.
+func Verify(before, after []byte) error {
+	beforeLines := strings.Split(string(before), "\n")
+	afterLines := strings.Split(string(after), "\n")
+
+	if err := compare("line", countLines(beforeLines), countLines(afterLines)); err != nil {
+		return err
+	}
+
+	beforeBlocks, err := tokeniser.Tokenize(beforeLines)
+	if err != nil {
+		return &VerifyError{Message: "input can't be tokenised: " + err.Error()}
+	}
+	afterBlocks, err := tokeniser.Tokenize(afterLines)
+	if err != nil {
+		return &VerifyError{Message: "output can't be tokenised: " + err.Error()}
+	}
+
+	beforeMembers, afterMembers := newMultiset(), newMultiset()
+	countMembers(beforeMembers, beforeBlocks, "")
+	countMembers(afterMembers, afterBlocks, "")
+	return compare("member", beforeMembers, afterMembers)
+}
+
+// multiset counts keys, label is what a key is called in errors
+type multiset struct {
+	counts map[string]int
+	labels map[string]string
+}
+
+func newMultiset() multiset {
+	return multiset{counts: map[string]int{}, labels: map[string]string{}}
+}
+
+func (ms multiset) add(key string, label string) {
+	ms.counts[key]++
+	ms.labels[key] = label
+}
+
+// countMembers counts every member by its lines, prefixed with the classes it is in
+func countMembers(ms multiset, blocks []tk.Block, parent string) {
+	for _, block := range blocks {
+		lines := normaliseLines(slices.Concat(block.Content, block.End))
+		label := ""
+		if len(lines) > 0 {
+			label = strings.TrimSpace(lines[0])
+		}
+
+		slices.Sort(lines)
+		key := parent + strings.Join(lines, "\n")
+		ms.add(key, label)
+
+		// Accessors must stay with their own declaration, in order
+		for _, p := range block.Properties {
+			accessors := normaliseLines(block.Content[p.Start : p.End+1])
+			ms.add(key+"\n= "+strings.Join(accessors, "\n"), strings.TrimSpace(accessors[0]))
+		}
+
+		countMembers(ms, block.Children, key+"\n> ")
+	}
+}
+
+func countLines(lines []string) multiset {
+	ms := newMultiset()
+	for _, line := range normaliseLines(lines) {
+		ms.add(line, strings.TrimSpace(line))
+	}
+	return ms
+}
+
+// normaliseLines returns the non blank logical lines as their indent and tokens,
+// so changes to spacing between tokens and trailing commas don't count as changes.
+func normaliseLines(lines []string) []string {
+	tokens, err := lexer.Lex(strings.Join(lines, "\n"))
+	if err != nil {
+		// Compare the physical lines of anything that can't be lexed
+		var out []string
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				out = append(out, strings.TrimRight(line, " \t\r"))
+			}
+		}
+		return out
+	}
+
+	var out []string
+	var line []string
+	depth, lineDepth := 0, 0
+	comma := -1 // index in line of a comma with only comments after it
+	for _, t := range tokens {
+		switch t.Kind {
+		case lexer.Indent:
+			depth++
+		case lexer.Dedent:
+			depth--
+		case lexer.Newline, lexer.EOF:
+			if len(line) > 0 {
+				out = append(out, strings.Repeat("\t", lineDepth)+strings.Join(line, " "))
+			}
+			line, comma = nil, -1
+		default:
+			if len(line) == 0 {
+				lineDepth = depth
+				if t.Kind == lexer.Comment {
+					// Comment lines don't take part in indentation
+					lineDepth = 0
+				}
+			}
+			if comma >= 0 && t.Kind == lexer.Punctuation && strings.Contains(")]}", t.Text) {
+				line = slices.Delete(line, comma, comma+1)
+			}
+			switch {
+			case t.Is(lexer.Punctuation, ","):
+				comma = len(line)
+			case t.Kind != lexer.Comment:
+				comma = -1
+			}
+			if t.Kind == lexer.Comment {
+				// Trailing whitespace is stripped
+				t.Text = strings.TrimRight(t.Text, " \t")
+			}
+			line = append(line, t.Text)
+		}
+	}
+	return out
+}
+
+// compare reports the first entry the two multisets disagree on
+func compare(what string, before, after multiset) error {
+	keys := make([]string, 0, len(before.counts)+len(after.counts))
+	for k := range before.counts {
+		keys = append(keys, k)
+	}
+	for k := range after.counts {
+		if _, ok := before.counts[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		switch b, a := before.counts[k], after.counts[k]; {
+		case a < b:
+			return &VerifyError{Message: fmt.Sprintf("%s lost: %q", what, before.labels[k])}
+		case a > b:
+			return &VerifyError{Message: fmt.Sprintf("%s added: %q", what, after.labels[k])}
+		}
+	}
+	return nil
+}
+
+// IdempotencyError is returned when formatting already formatted source changes it again, Line is 1-based
+type IdempotencyError struct {
+	Line   int
+	First  string
+	Second string
+}
+
+func (ierr *IdempotencyError) Error() string {
+	return fmt.Sprintf("second pass changes line %d: %q became %q", ierr.Line, ierr.First, ierr.Second)
+}
+
+// VerifyIdempotent formats src twice and checks the second pass changes nothing
+func VerifyIdempotent(src []byte, opts Options) error {
+	first, err := Format(src, opts)
+	if err != nil {
+		return err
+	}
+	second, err := Format(first, opts)
+	if err != nil {
+		return err
+	}
+
+	firstLines := strings.Split(string(first), "\n")
+	secondLines := strings.Split(string(second), "\n")
+	for i := 0; i < max(len(firstLines), len(secondLines)); i++ {
+		var a, b string
+		if i < len(firstLines) {
+			a = firstLines[i]
+		}
+		if i < len(secondLines) {
+			b = secondLines[i]
+		}
+		if a != b || i >= len(firstLines) || i >= len(secondLines) {
+			return &IdempotencyError{Line: i + 1, First: a, Second: b}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package format
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		ok     bool
+	}{
+		{
+			name:   "Reordered members",
+			before: "func f():\n\tpass\nvar a\nextends Node",
+			after:  "extends Node\n\nvar a\n\nfunc f():\n\tpass",
+			ok:     true,
+		},
+		{
+			name:   "Spacing and indent style",
+			before: "var a=1\nfunc f():\n    return a",
+			after:  "var a = 1\n\nfunc f():\n\treturn a",
+			ok:     true,
+		},
+		{
+			name:   "Wrapped list with a trailing comma",
+			before: "func f(a, b):\n\tpass",
+			after:  "func f(\n\ta,\n\tb,\n):\n\tpass",
+			ok:     true,
+		},
+		{
+			name:   "Comma between items is kept",
+			before: "var a = [1, 2]",
+			after:  "var a = [1 2]",
+		},
+		{
+			name:   "Lost comment",
+			before: "# about a\nvar a\n# end",
+			after:  "# about a\nvar a",
+		},
+		{
+			name:   "Duplicated member",
+			before: "var a\nvar b",
+			after:  "var a\nvar b\nvar a",
+		},
+		{
+			name:   "Line moved between members",
+			before: "func f():\n\tpass\n\tprint(1)\nfunc g():\n\tpass",
+			after:  "func f():\n\tpass\nfunc g():\n\tpass\n\tprint(1)",
+		},
+		{
+			name:   "Accessors moved between properties",
+			before: "var a:\n\tget:\n\t\treturn 1\nvar b:\n\tget:\n\t\treturn 2",
+			after:  "var a:\n\tget:\n\t\treturn 2\nvar b:\n\tget:\n\t\treturn 1",
+		},
+		{
+			name:   "Member moved between classes",
+			before: "class A:\n\tvar x\nclass B:\n\tvar y",
+			after:  "class A:\n\tvar y\nclass B:\n\tvar x",
+		},
+		{
+			name:   "Changed indentation",
+			before: "func f():\n\tif a:\n\t\tpass\n\tpass",
+			after:  "func f():\n\tif a:\n\t\tpass\n\t\tpass",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify([]byte(tt.before), []byte(tt.after))
+			if tt.ok && err != nil {
+				t.Errorf("Verify returned error: %v", err)
+			}
+
+			var verr *VerifyError
+			if !tt.ok && !errors.As(err, &verr) {
+				t.Errorf("expected a VerifyError, got %v", err)
+			}
+		})
+	}
+}
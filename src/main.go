@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"godot_linter/config"
 	"godot_linter/printer"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +20,14 @@ import (
 
 const ROOT = "./"
 
+// Exit codes of check mode
+const (
+	EXIT_UNFORMATTED       = 1
+	EXIT_TOKENISER_FAILURE = 2
+	EXIT_REFUSED           = 3
+	EXIT_FAILED            = 4
+)
+
 func main() {
 	cmd := &cli.Command{
 		Name:      "Godot Beautifier",
@@ -37,6 +48,37 @@ func main() {
 				Value:   false,
 				Usage:   "don't write changed files, use with verbose for testing",
 			},
+			&cli.BoolFlag{
+				Name:    "check",
+				Aliases: []string{"c"},
+				Value:   false,
+				Usage: fmt.Sprintf("don't write files, list the ones that would change and exit with %d if any would (%d if any fail to tokenise, %d if formatting any would change its code, %d if any fail otherwise)",
+					EXIT_UNFORMATTED, EXIT_TOKENISER_FAILURE, EXIT_REFUSED, EXIT_FAILED),
+			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Value: false,
+				Usage: "don't write files, print a unified diff of the changes to stdout (other output goes to stderr)",
+			},
+			&cli.BoolFlag{
+				Name:  "verify-idempotent",
+				Value: false,
+				Usage: fmt.Sprintf("don't write files, format each twice and exit with %d if a second pass would change any", EXIT_UNFORMATTED),
+			},
+			&cli.BoolFlag{
+				Name:  "migrate-godot3",
+				Value: false,
+				Usage: "rewrite Godot 3 syntax (tool, export, onready, setget, yield) to Godot 4",
+			},
+			&cli.BoolFlag{
+				Name:  "stdin",
+				Value: false,
+				Usage: "format a script read from stdin and print it to stdout, same as passing - as the path",
+			},
+			&cli.StringFlag{
+				Name:  "stdin-filename",
+				Usage: "path of the script read from stdin, used to find its config and in messages",
+			},
 			&cli.StringSliceFlag{
 				Name:  "except",
 				Value: []string{"addons"},
@@ -57,6 +99,39 @@ func main() {
 				printer.UseANSI = false
 			}
 
+			// Keep stdout for the diff only
+			show_diff := cmd.Bool("diff")
+			if show_diff {
+				printer.Output = os.Stderr
+			}
+
+			// Stdout only gets the formatted source
+			if cmd.Bool("stdin") || (cmd.NArg() == 1 && cmd.Args().Get(0) == "-") {
+				if cmd.NArg() > 1 || (cmd.NArg() == 1 && cmd.Args().Get(0) != "-") {
+					printer.PrintError("Too many arguments, don't provide a path when reading from stdin")
+					os.Exit(1)
+				}
+				printer.Output = os.Stderr
+
+				name := cmd.String("stdin-filename")
+				config_path := name
+				if name == "" {
+					name = "stdin"
+					config_path = ROOT
+				}
+
+				lint_stdin(name, styler.Options{
+					Config:  load_config(config_path, cmd.Bool("verbose")),
+					Verbose: cmd.Bool("verbose"),
+					Check:   cmd.Bool("check"),
+					Diff:    show_diff,
+
+					VerifyIdempotent: cmd.Bool("verify-idempotent"),
+					MigrateGodot3:    cmd.Bool("migrate-godot3"),
+				})
+				return nil
+			}
+
 			var input_path string
 			if cmd.NArg() == 1 {
 				input_path = cmd.Args().Get(0)
@@ -69,6 +144,10 @@ func main() {
 
 			printer.PrintNormal(fmt.Sprintf("Using godot project at: `%s`", input_path))
 
+			cfg := load_config(input_path, cmd.Bool("verbose"))
+			check := cmd.Bool("check")
+			verify_idempotent := cmd.Bool("verify-idempotent")
+
 			var files []string
 			if strings.HasSuffix(input_path, ".gd") {
 				// Is single file
@@ -110,7 +189,7 @@ func main() {
 				printer.PrintNormal("GDScript files found:")
 				printer.PPrintArray(files)
 
-				if !cmd.Bool("no-confirm") {
+				if !cmd.Bool("no-confirm") && !check && !show_diff && !verify_idempotent {
 					keep_going := printer.AskConfirmation("Continue to process?")
 					if !keep_going {
 						printer.PrintNormal("Exiting")
@@ -119,12 +198,41 @@ func main() {
 				}
 			}
 
+			opts := styler.Options{
+				Config:  cfg,
+				Verbose: cmd.Bool("verbose"),
+				Dry:     cmd.Bool("dry"),
+				Check:   check,
+				Diff:    show_diff,
+
+				VerifyIdempotent: verify_idempotent,
+				MigrateGodot3:    cmd.Bool("migrate-godot3"),
+			}
+
+			if verify_idempotent {
+				summary := lint_files_mt(files, opts)
+				report_idempotent(summary)
+				return nil
+			}
+
+			if check {
+				summary := lint_files_mt(files, opts)
+				report_check(summary)
+				return nil
+			}
+
+			if show_diff {
+				summary := lint_files_mt(files, opts)
+				printer.PrintNormal(fmt.Sprintf("Diffed %d files (%d failed)", summary.total, summary.errored))
+				return nil
+			}
+
 			backup_files(input_path, files)
 
 			start := time.Now() // Before line
-			total, errored := lint_files_mt(files, cmd.Bool("v"), cmd.Bool("d"))
+			summary := lint_files_mt(files, opts)
 			elapsed := time.Since(start) // After line
-			printer.PrintNormal(fmt.Sprintf("Execution took %s for %d files (%d failed)", elapsed, total, errored))
+			printer.PrintNormal(fmt.Sprintf("Execution took %s for %d files (%d failed)", elapsed, summary.total, summary.errored))
 
 			return nil
 		},
@@ -150,6 +258,26 @@ func scan_gd_files(local_root string) ([]string, error) {
 	return matches, err
 }
 
+// load_config finds the closest config file above the input path, the default config is used if there is none
+func load_config(input_path string, verbose bool) config.Config {
+	path, found := config.Find(input_path)
+	if !found {
+		return config.Default()
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		printer.PrintError("Not continuing, invalid config: " + err.Error())
+		os.Exit(1)
+	}
+
+	printer.PrintNormal("Using config at: " + path)
+	if verbose {
+		printer.PrintInfo("Block order: " + cfg.OrderNames())
+	}
+	return cfg
+}
+
 func backup_files(local_root string, locations []string) error {
 	path, err := NewBackup(local_root, locations)
 
@@ -164,19 +292,50 @@ func backup_files(local_root string, locations []string) error {
 	return nil
 }
 
-func lint_files_mt(files []string, verbose bool, dry bool) (total int, errored int) {
+type lint_summary struct {
+	total            int
+	errored          int
+	tokeniser_failed int
+	refused          int
+	not_idempotent   []string
+	unformatted      []string
+}
+
+func lint_files_mt(files []string, opts styler.Options) lint_summary {
 	var wg sync.WaitGroup
 	var error_wg sync.WaitGroup
 
 	ch := make(chan error)
-	not_completed := 0
+	summary := lint_summary{total: len(files)}
 
 	error_wg.Add(1)
 	go func() {
 		defer error_wg.Done()
 		for state := range ch {
+			var nerr styler.NotFormattedError
+			if errors.As(state, &nerr) {
+				summary.unformatted = append(summary.unformatted, nerr.FilePath)
+				continue
+			}
+
+			var ierr styler.IdempotencyError
+			if errors.As(state, &ierr) {
+				summary.not_idempotent = append(summary.not_idempotent, ierr.FilePath)
+			}
+
 			printer.PrintWarning(state.Error())
-			not_completed++
+
+			var terr styler.TokenizerError
+			if errors.As(state, &terr) {
+				summary.tokeniser_failed++
+				print_error_lines(terr)
+			}
+
+			var verr styler.VerifyError
+			if errors.As(state, &verr) {
+				summary.refused++
+			}
+			summary.errored++
 		}
 	}()
 
@@ -185,7 +344,7 @@ func lint_files_mt(files []string, verbose bool, dry bool) (total int, errored i
 
 		go func(path string) {
 			defer wg.Done()
-			styler.LintFile(path, ch, verbose, dry)
+			styler.LintFile(path, ch, opts)
 		}(file)
 	}
 
@@ -194,7 +353,84 @@ func lint_files_mt(files []string, verbose bool, dry bool) (total int, errored i
 	close(ch)
 	error_wg.Wait()
 
-	return len(files), not_completed
+	return summary
+}
+
+// lint_stdin formats a script from stdin to stdout, on failure nothing is written to stdout
+func lint_stdin(name string, opts styler.Options) {
+	err := styler.LintStream(os.Stdin, os.Stdout, name, opts)
+	if err == nil {
+		return
+	}
+
+	var nerr styler.NotFormattedError
+	if errors.As(err, &nerr) {
+		printer.PrintWarning("Would reformat: " + name)
+		os.Exit(EXIT_UNFORMATTED)
+	}
+
+	printer.PrintError(err.Error())
+
+	var terr styler.TokenizerError
+	if errors.As(err, &terr) {
+		print_error_lines(terr)
+		os.Exit(EXIT_TOKENISER_FAILURE)
+	}
+
+	var verr styler.VerifyError
+	if errors.As(err, &verr) {
+		os.Exit(EXIT_REFUSED)
+	}
+
+	var ierr styler.IdempotencyError
+	if errors.As(err, &ierr) {
+		os.Exit(EXIT_UNFORMATTED)
+	}
+	os.Exit(EXIT_FAILED)
+}
+
+// print_error_lines prints the lines that failed to tokenise as path:line:col: message, for editors to jump to
+func print_error_lines(terr styler.TokenizerError) {
+	for _, l := range terr.Lines {
+		printer.PrintPlain(fmt.Sprintf("%s:%d:%d: %s: %s", terr.FilePath, l.Line, l.Col, l.Message, l.Text))
+	}
+}
+
+// report_check lists the files check mode found unformatted and exits with the matching code
+func report_check(summary lint_summary) {
+	slices.Sort(summary.unformatted)
+	for _, path := range summary.unformatted {
+		printer.PrintWarning("Would reformat: " + path)
+	}
+
+	printer.PrintNormal(fmt.Sprintf("%d of %d files would be reformatted (%d failed)",
+		len(summary.unformatted), summary.total, summary.errored))
+
+	switch {
+	case summary.tokeniser_failed > 0:
+		os.Exit(EXIT_TOKENISER_FAILURE)
+	case summary.refused > 0:
+		os.Exit(EXIT_REFUSED)
+	case summary.errored > 0:
+		os.Exit(EXIT_FAILED)
+	case len(summary.unformatted) > 0:
+		os.Exit(EXIT_UNFORMATTED)
+	}
+}
+
+// report_idempotent sums up idempotency mode and exits with the matching code
+func report_idempotent(summary lint_summary) {
+	printer.PrintNormal(fmt.Sprintf("%d of %d files aren't idempotent (%d failed)",
+		len(summary.not_idempotent), summary.total, summary.errored-len(summary.not_idempotent)))
+
+	switch {
+	case summary.tokeniser_failed > 0:
+		os.Exit(EXIT_TOKENISER_FAILURE)
+	case summary.errored > len(summary.not_idempotent):
+		os.Exit(EXIT_FAILED)
+	case len(summary.not_idempotent) > 0:
+		os.Exit(EXIT_UNFORMATTED)
+	}
 }
 
 func makePathLocal(path string, local_root string) string {
@@ -3,12 +3,16 @@ package printer
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
 var UseANSI = true
 
+// Output receives all messages, set it to os.Stderr to keep stdout for results like diffs
+var Output io.Writer = os.Stdout
+
 // helper: wrap s in code and reset if UseANSI, otherwise return s unmodified.
 func wrap(s, code string) string {
 	if UseANSI {
@@ -20,38 +24,43 @@ func wrap(s, code string) string {
 func PrintError(warning string) {
 	prefix := wrap("[!]: ", BoldRed)
 	msg := wrap(warning, Red)
-	fmt.Println(prefix + msg)
+	fmt.Fprintln(Output, prefix+msg)
 }
 
 func PrintWarning(warning string) {
 	prefix := wrap("[x]: ", BoldYellow)
 	msg := wrap(warning, Yellow)
-	fmt.Println(prefix + msg)
+	fmt.Fprintln(Output, prefix+msg)
 }
 
 func PrintNormal(warning string) {
 	prefix := wrap("[~]: ", BoldCyan)
 	msg := wrap(warning, "")
-	fmt.Println(prefix + msg)
+	fmt.Fprintln(Output, prefix+msg)
 }
 
 func PrintSuccess(warning string) {
 	prefix := wrap("[✓]: ", BoldGreen)
 	msg := wrap(warning, "")
-	fmt.Println(prefix + msg)
+	fmt.Fprintln(Output, prefix+msg)
 }
 
 func PrintInfo(warning string) {
 	prefix := wrap("[i]: ", BoldBlue)
 	msg := wrap(warning, "")
-	fmt.Println(prefix + msg)
+	fmt.Fprintln(Output, prefix+msg)
+}
+
+// PrintPlain prints msg as is, for lines other tools parse
+func PrintPlain(msg string) {
+	fmt.Fprintln(Output, msg)
 }
 
 func PrintObvious(msg string) {
 	if UseANSI {
-		fmt.Printf("\033[1;5;91m[!]: %s\033[0m\n", msg)
+		fmt.Fprintf(Output, "\033[1;5;91m[!]: %s\033[0m\n", msg)
 	} else {
-		fmt.Printf("[!]: %s\n", msg)
+		fmt.Fprintf(Output, "[!]: %s\n", msg)
 	}
 }
 
@@ -74,41 +83,41 @@ func PrintBanner() {
 		"",
 	}
 	for _, line := range lines {
-		fmt.Println(line)
+		fmt.Fprintln(Output, line)
 	}
 }
 
 func PPrintArray(arr []string) {
 	// Dim only if ANSI
 	if UseANSI {
-		fmt.Print(Dim)
+		fmt.Fprint(Output, Dim)
 	}
 	limit, n := 5, len(arr)
 	for i := 0; i < n && i < limit; i++ {
-		fmt.Printf("  %s", arr[i])
+		fmt.Fprintf(Output, "  %s", arr[i])
 		if i != limit-1 && i != n-1 {
-			fmt.Println(",")
+			fmt.Fprintln(Output, ",")
 		}
 	}
 	if n > limit {
-		fmt.Printf(",\n  + %d more lines", n-limit)
+		fmt.Fprintf(Output, ",\n  + %d more lines", n-limit)
 	}
 	if UseANSI {
-		fmt.Println("\n" + Reset)
+		fmt.Fprintln(Output, "\n"+Reset)
 	} else {
-		fmt.Println()
+		fmt.Fprintln(Output)
 	}
 }
 
 func DebugPrintArray(arr []string) {
-	fmt.Print("[")
+	fmt.Fprint(Output, "[")
 	for i, s := range arr {
-		fmt.Printf("'%s'", s)
+		fmt.Fprintf(Output, "'%s'", s)
 		if i != len(arr)-1 {
-			fmt.Printf(", ")
+			fmt.Fprintf(Output, ", ")
 		}
 	}
-	fmt.Print("]\n")
+	fmt.Fprint(Output, "]\n")
 }
 
 func AskConfirmation(prompt string) bool {
@@ -116,10 +125,10 @@ func AskConfirmation(prompt string) bool {
 
 	for {
 		p := wrap("[?]: ", Magenta)
-		fmt.Printf("%s %s [Y/n]:", p, prompt)
+		fmt.Fprintf(Output, "%s %s [Y/n]:", p, prompt)
 		input, err := reader.ReadString('\n')
 		if err != nil {
-			fmt.Println("Error reading input.")
+			fmt.Fprintln(Output, "Error reading input.")
 			continue
 		}
 
@@ -129,6 +138,31 @@ func AskConfirmation(prompt string) bool {
 		} else if input == "n" || input == "no" {
 			return false
 		}
-		fmt.Println("Please enter 'y' or 'n'.")
+		fmt.Fprintln(Output, "Please enter 'y' or 'n'.")
+	}
+}
+
+// PrintDiff writes a unified diff to stdout, coloured by line if UseANSI
+func PrintDiff(diff string) {
+	if !UseANSI {
+		fmt.Fprint(os.Stdout, diff)
+		return
+	}
+
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			sb.WriteString(Bold + strings.TrimSuffix(line, "\n") + Reset + "\n")
+		case strings.HasPrefix(line, "@@"):
+			sb.WriteString(Cyan + strings.TrimSuffix(line, "\n") + Reset + "\n")
+		case strings.HasPrefix(line, "-"):
+			sb.WriteString(Red + strings.TrimSuffix(line, "\n") + Reset + "\n")
+		case strings.HasPrefix(line, "+"):
+			sb.WriteString(Green + strings.TrimSuffix(line, "\n") + Reset + "\n")
+		default:
+			sb.WriteString(line)
+		}
 	}
+	fmt.Fprint(os.Stdout, sb.String())
 }
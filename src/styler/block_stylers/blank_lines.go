@@ -0,0 +1,83 @@
+package block_stylers
+
+import (
+	"strings"
+
+	"godot_linter/styler/lexer"
+)
+
+// StyleBlankLines strips trailing whitespace and shortens runs of blank lines to at most maxBlank,
+// blank lines right after a `func` line and right before a dedent are removed.
+// Lines inside multi-line strings are kept as they are.
+func StyleBlankLines(lines []string, maxBlank int) []string {
+	tokens, err := lexer.Lex(strings.Join(lines, "\n"))
+	if err != nil {
+		return lines
+	}
+	inString := lexer.StringLines(tokens, len(lines))
+	funcLines := funcHeaderEnds(tokens, len(lines))
+
+	// Indent of the code at or below each line, comments don't count as they may be unindented
+	below := make([]int, len(lines)+1)
+	for i := len(lines) - 1; i >= 0; i-- {
+		below[i] = below[i+1]
+		if trimmed := strings.TrimSpace(lines[i]); trimmed != "" && !strings.HasPrefix(trimmed, "#") && !inString[i] {
+			below[i] = countIndent(lines[i])
+		}
+	}
+
+	var out []string
+	blanks := 0
+	afterFunc := false
+	above := 0 // indent of the code above
+	for i, line := range lines {
+		if i+1 == len(lines) || !inString[i+1] {
+			// The line doesn't end inside a string
+			line = strings.TrimRight(line, " \t")
+		}
+
+		if line == "" && !inString[i] {
+			blanks++
+			continue
+		}
+
+		if blanks > 0 && !afterFunc && below[i] >= above {
+			for range min(blanks, maxBlank) {
+				out = append(out, "")
+			}
+		}
+		blanks = 0
+		out = append(out, line)
+
+		afterFunc = funcLines[i]
+		if trimmed := strings.TrimSpace(line); !strings.HasPrefix(trimmed, "#") && !inString[i] {
+			above = countIndent(line)
+		}
+	}
+	return out
+}
+
+// funcHeaderEnds marks the lines a `func` line ends on, when it opens an indented body
+func funcHeaderEnds(tokens []lexer.Token, n int) []bool {
+	ends := make([]bool, n)
+	isFunc := false
+	var last lexer.Token
+	for _, t := range tokens {
+		switch t.Kind {
+		case lexer.Newline:
+			if isFunc && last.Is(lexer.Punctuation, ":") && last.Depth == 0 && t.Line-1 < n {
+				ends[t.Line-1] = true
+			}
+			isFunc = false
+		case lexer.Indent, lexer.Dedent, lexer.Comment, lexer.EOF:
+		default:
+			isFunc = isFunc || t.Is(lexer.Keyword, "func") && t.Depth == 0
+			last = t
+		}
+	}
+	return ends
+}
+
+func countIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, "\t"))
+}
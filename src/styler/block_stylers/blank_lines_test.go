@@ -0,0 +1,56 @@
+package block_stylers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStyleBlankLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		max      int
+		expected string
+	}{
+		{
+			name:     "Runs are shortened",
+			input:    "func f():\n\ta()\n\n\n\n\tb()",
+			max:      1,
+			expected: "func f():\n\ta()\n\n\tb()",
+		},
+		{
+			name:     "Configured maximum",
+			input:    "func f():\n\ta()\n\n\n\n\tb()\n\n\tc()",
+			max:      2,
+			expected: "func f():\n\ta()\n\n\n\tb()\n\n\tc()",
+		},
+		{
+			name:     "No blank lines after func or before a dedent",
+			input:    "func f(\n\ta,\n):\n\n\tif a:\n\t\tb()\n\n\tc()\n\n",
+			max:      1,
+			expected: "func f(\n\ta,\n):\n\tif a:\n\t\tb()\n\tc()",
+		},
+		{
+			name:     "Unindented comments aren't a dedent",
+			input:    "func f():\n\ta()\n\n# note\n\tb()",
+			max:      1,
+			expected: "func f():\n\ta()\n\n# note\n\tb()",
+		},
+		{
+			name:     "Trailing whitespace is stripped outside strings",
+			input:    "func f(): \t\n\tvar s = \"\"\"a  \n\n\n\n b\"\"\"  \n\tpass # done  ",
+			max:      1,
+			expected: "func f():\n\tvar s = \"\"\"a  \n\n\n\n b\"\"\"\n\tpass # done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := StyleBlankLines(strings.Split(tt.input, "\n"), tt.max)
+			if !reflect.DeepEqual(actual, strings.Split(tt.expected, "\n")) {
+				t.Errorf("StyleBlankLines failed.\nInput:\n%q\nExpected:\n%q\nGot:\n%q", tt.input, tt.expected, strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package block_stylers
+
+import (
+	"slices"
+	"strings"
+
+	"godot_linter/styler/lexer"
+)
+
+// Keywords called like functions, without a space before their `(`
+var callKeywords = []string{"assert", "func", "preload", "super", "yield"}
+
+// Keywords that are values, an operator after them is binary
+var valueKeywords = []string{"false", "null", "self", "super", "true"}
+
+// StyleSpacing normalises the spaces between the tokens of each line: one space around binary operators,
+// `:=` and `->`, after commas and colons, none inside brackets or before a call's `(`.
+// Indents, line breaks and the space before a comment are kept, strings and comments are never changed.
+func StyleSpacing(lines []string) []string {
+	src := strings.Join(lines, "\n")
+	tokens, err := lexer.Lex(src)
+	if err != nil {
+		return lines
+	}
+
+	var out strings.Builder
+	pos := 0
+	var prev *lexer.Token
+	prevUnary := false
+	for i, t := range tokens {
+		switch t.Kind {
+		case lexer.Newline:
+			prev = nil
+			continue
+		case lexer.Indent, lexer.Dedent, lexer.EOF:
+			continue
+		}
+
+		gap := src[pos:t.Offset]
+		if prev != nil && t.Kind != lexer.Comment && strings.Trim(gap, " \t") == "" {
+			gap = space(*prev, t, prevUnary)
+		}
+		out.WriteString(gap)
+		out.WriteString(t.Text)
+		pos = t.End()
+
+		prevUnary = isUnary(t, prev)
+		prev = &tokens[i]
+	}
+	out.WriteString(src[pos:])
+
+	return strings.Split(out.String(), "\n")
+}
+
+// space returns the space between two tokens on the same line
+func space(prev, next lexer.Token, prevUnary bool) string {
+	switch {
+	case isPunctuation(prev, "(", "[", "{"), isPunctuation(next, ")", "]", "}"):
+		return ""
+	case isPunctuation(next, ",", ";", ":"):
+		return ""
+	case isPunctuation(prev, ",", ";", ":"):
+		return " "
+	case isPunctuation(prev, "."), isPunctuation(next, "."):
+		return ""
+	case prevUnary:
+		return ""
+	case prev.Kind == lexer.Operator, next.Kind == lexer.Operator:
+		return " "
+	case isPunctuation(next, "(", "["):
+		// Calls and subscripts
+		switch {
+		case prev.Kind == lexer.Keyword:
+			if slices.Contains(callKeywords, prev.Text) {
+				return ""
+			}
+			return " "
+		case prev.Kind == lexer.Operator, prev.Kind == lexer.Punctuation && !isPunctuation(prev, ")", "]", "}"):
+			return " "
+		}
+		return ""
+	}
+	return " "
+}
+
+// isUnary reports whether t is an operator applied to the operand after it
+func isUnary(t lexer.Token, prev *lexer.Token) bool {
+	if t.Kind != lexer.Operator {
+		return false
+	}
+	switch t.Text {
+	case "!", "~":
+		return true
+	case "-", "+":
+		return prev == nil ||
+			prev.Kind == lexer.Operator ||
+			prev.Kind == lexer.Punctuation && !isPunctuation(*prev, ")", "]", "}") ||
+			prev.Kind == lexer.Keyword && !slices.Contains(valueKeywords, prev.Text)
+	}
+	return false
+}
+
+func isPunctuation(t lexer.Token, texts ...string) bool {
+	return t.Kind == lexer.Punctuation && slices.Contains(texts, t.Text)
+}
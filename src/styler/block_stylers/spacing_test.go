@@ -0,0 +1,65 @@
+package block_stylers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStyleSpacing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Type hints and assignment",
+			input:    "var x : int=1\nvar y:=2\nconst Z :float = 1.0",
+			expected: "var x: int = 1\nvar y := 2\nconst Z: float = 1.0",
+		},
+		{
+			name:     "Function signature",
+			input:    "func f( a:int,b = 2 )->void :\n\treturn a+b*-1",
+			expected: "func f(a: int, b = 2) -> void:\n\treturn a + b * -1",
+		},
+		{
+			name:     "Calls, subscripts and unary operators",
+			input:    "\tprint (a [0] , -b , not c , !d)\n\tx = - y\n\tz = (1) - 2",
+			expected: "\tprint(a[0], -b, not c, !d)\n\tx = -y\n\tz = (1) - 2",
+		},
+		{
+			name:     "Collections",
+			input:    "var d = { \"a\" : [ 1,2 ], \"b\":{} }\nenum E { A,B }",
+			expected: "var d = {\"a\": [1, 2], \"b\": {}}\nenum E {A, B}",
+		},
+		{
+			name:     "Strings, comments and node paths are kept",
+			input:    "var s = \"a  ,b:c\"+'x'   #  a ,b\nvar n = $Path/To  .  get_node(%Unique)",
+			expected: "var s = \"a  ,b:c\" + 'x'   #  a ,b\nvar n = $Path/To.get_node(%Unique)",
+		},
+		{
+			name:     "Node paths through unique names are kept",
+			input:    "@onready var u = %Unique/Inner\n@onready var v = $A/%B\n@onready var w = %A/%B",
+			expected: "@onready var u = %Unique/Inner\n@onready var v = $A/%B\n@onready var w = %A/%B",
+		},
+		{
+			name:     "Line breaks and continuations are kept",
+			input:    "var a = [\n\t1 ,\n\t2,\n]\nvar b = 1 + \\\n\t2",
+			expected: "var a = [\n\t1,\n\t2,\n]\nvar b = 1 + \\\n\t2",
+		},
+		{
+			name:     "Keywords before brackets",
+			input:    "\tif(a):\n\t\treturn[1]\n\tvar l = func (x): return preload (\"res://a.gd\")",
+			expected: "\tif (a):\n\t\treturn [1]\n\tvar l = func(x): return preload(\"res://a.gd\")",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := StyleSpacing(strings.Split(tt.input, "\n"))
+			if !reflect.DeepEqual(actual, strings.Split(tt.expected, "\n")) {
+				t.Errorf("StyleSpacing failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
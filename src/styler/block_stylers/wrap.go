@@ -0,0 +1,139 @@
+package block_stylers
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"godot_linter/styler/cst"
+	"godot_linter/styler/lexer"
+)
+
+// Wrap breaks lines longer than width at their brackets, one item per line with a trailing comma.
+// The longest bracketed list on a line is broken first, until the line fits or has no lists left on one line.
+// Tabs count as lexer.IndentWidth columns, a width of 0 or less leaves the lines as they are.
+func Wrap(lines []string, width int) []string {
+	if width <= 0 {
+		return lines
+	}
+
+	src := strings.Join(lines, "\n")
+	tree, err := cst.Parse(src)
+	if err != nil {
+		return lines
+	}
+	lists := collectLists(tree)
+
+	// Every line breaks on its own, from the lists of the one parse
+	var out []string
+	start := 0
+	for i, line := range lines {
+		end := start + len(line)
+		out = wrapLine(out, src, "", start, end, "", lists[i+1], width)
+		start = end + 1
+	}
+	return out
+}
+
+// collectLists returns the lists opened and closed on one line that may be broken, by line
+func collectLists(tree *cst.Node) map[int][]*cst.Node {
+	lists := map[int][]*cst.Node{}
+	var walk func(n *cst.Node) bool
+	walk = func(n *cst.Node) bool {
+		if !noTrailingComma(n) {
+			return collectList(n, lists)
+		}
+
+		// Only what's inside the list is broken
+		nodes := n.Nodes()
+		cst.Walk(nodes[0], walk)
+		for _, item := range nodes[1].Nodes() {
+			cst.Walk(item, walk)
+		}
+		return false
+	}
+	cst.Walk(tree, walk)
+	return lists
+}
+
+// wrapLine appends the line of prefix, src[start:end] and suffix to out. While it is longer than width
+// the longest of its lists is broken, and the lines that gives are wrapped the same way.
+func wrapLine(out []string, src string, prefix string, start, end int, suffix string, lists []*cst.Node, width int) []string {
+	text := prefix + src[start:end] + suffix
+	if columns(text) <= width {
+		return append(out, text)
+	}
+
+	var longest *cst.Node
+	for _, list := range lists {
+		if longest == nil || listLen(list) > listLen(longest) {
+			longest = list
+		}
+	}
+	if longest == nil {
+		return append(out, text)
+	}
+
+	// Items go one level deeper than the line the list is on
+	tokens := longest.Tokens()
+	open, close := tokens[0], tokens[len(tokens)-1]
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+
+	out = wrapLine(out, src, prefix, start, open.End(), "", within(lists, start, open.Offset), width)
+	for _, item := range longest.Nodes() {
+		expr := item.Nodes()[0].Tokens()
+		from, to := expr[0].Offset, expr[len(expr)-1].End()
+		out = wrapLine(out, src, indent+"\t", from, to, ",", within(lists, from, to), width)
+	}
+	return wrapLine(out, src, indent, close.Offset, end, suffix, within(lists, close.End(), end), width)
+}
+
+// within returns the lists between the offsets start and end
+func within(lists []*cst.Node, start, end int) []*cst.Node {
+	var inside []*cst.Node
+	for _, list := range lists {
+		tokens := list.Tokens()
+		if tokens[0].Offset >= start && tokens[len(tokens)-1].End() <= end {
+			inside = append(inside, list)
+		}
+	}
+	return inside
+}
+
+// noTrailingComma reports whether n is a subscript, typed array or `preload`/`assert` call,
+// whose list can't take a trailing comma
+func noTrailingComma(n *cst.Node) bool {
+	switch n.Kind {
+	case cst.Index:
+		return true
+	case cst.Call:
+		callee := n.Nodes()[0].First()
+		return callee.Is(lexer.Keyword, "preload") || callee.Is(lexer.Keyword, "assert")
+	}
+	return false
+}
+
+// collectList adds n to lists when it is a non empty list opened and closed on the same line
+func collectList(n *cst.Node, lists map[int][]*cst.Node) bool {
+	switch n.Kind {
+	case cst.List, cst.Array, cst.Dict:
+	default:
+		return true
+	}
+
+	tokens := n.Tokens()
+	open, close := tokens[0], tokens[len(tokens)-1]
+	if open.Line == close.Line && len(n.Nodes()) > 0 {
+		lists[open.Line] = append(lists[open.Line], n)
+	}
+	return true
+}
+
+func listLen(list *cst.Node) int {
+	tokens := list.Tokens()
+	return tokens[len(tokens)-1].End() - tokens[0].Offset
+}
+
+// columns is the display width of a line
+func columns(line string) int {
+	return utf8.RuneCountInString(line) + strings.Count(line, "\t")*(lexer.IndentWidth-1)
+}
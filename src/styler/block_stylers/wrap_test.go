@@ -0,0 +1,68 @@
+package block_stylers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{
+			name:     "Short lines are kept",
+			input:    "func f(a, b):\n\treturn [a, b]",
+			width:    40,
+			expected: "func f(a, b):\n\treturn [a, b]",
+		},
+		{
+			name:     "Longest list is broken first",
+			input:    "func f():\n\treturn call(a, [first_item, second_item])",
+			width:    40,
+			expected: "func f():\n\treturn call(\n\t\ta,\n\t\t[first_item, second_item],\n\t)",
+		},
+		{
+			name:     "Nested lists are broken until the lines fit",
+			input:    "var d = {\"key\": [first_item, second_item, third_item]}",
+			width:    30,
+			expected: "var d = {\n\t\"key\": [\n\t\tfirst_item,\n\t\tsecond_item,\n\t\tthird_item,\n\t],\n}",
+		},
+		{
+			name:     "Subscripts, typed arrays and preload get no trailing comma",
+			input:    "var a: Array[int] = values[index_of(first, second)] + [preload(\"res://a.gd\")]",
+			width:    40,
+			expected: "var a: Array[int] = values[index_of(\n\tfirst,\n\tsecond,\n)] + [\n\tpreload(\"res://a.gd\"),\n]",
+		},
+		{
+			name:     "Width 0 never breaks",
+			input:    "func f(first_argument, second_argument, third_argument):",
+			width:    0,
+			expected: "func f(first_argument, second_argument, third_argument):",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Wrap(strings.Split(tt.input, "\n"), tt.width)
+			if !reflect.DeepEqual(actual, strings.Split(tt.expected, "\n")) {
+				t.Errorf("Wrap failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+// BenchmarkWrap wraps a function of 2000 long calls, every line breaks on its own so the cost grows with the lines
+func BenchmarkWrap(b *testing.B) {
+	lines := []string{"func f():"}
+	for range 2000 {
+		lines = append(lines, "\tcall_something(first_argument, second_argument, [third_item, fourth_item, fifth_item])")
+	}
+
+	for b.Loop() {
+		Wrap(lines, 40)
+	}
+}
@@ -0,0 +1,197 @@
+package cst
+
+import (
+	"strings"
+
+	"godot_linter/styler/lexer"
+)
+
+// Kind is the syntactic class of a Node.
+type Kind uint8
+
+const (
+	File Kind = iota
+	Raw       // anything the parser did not recognise, kept token for token
+
+	// Members and statements
+	Comment
+	Annotation
+	ClassName
+	Extends
+	Signal
+	Enum
+	EnumValue
+	Const
+	Var
+	Accessor
+	Func
+	Class
+	Suite
+	If
+	Elif
+	Else
+	For
+	While
+	Match
+	Branch
+	Pattern
+	Return
+	KeywordStmt // pass, break, continue, breakpoint
+	ExprStmt
+	Assign
+
+	// Expressions
+	Name
+	Literal
+	Paren
+	Unary
+	Binary
+	Ternary
+	Call
+	Index
+	Attr
+	Await
+	Array
+	Dict
+	Pair
+	Lambda
+	List // bracketed, comma separated items of a call, parameter list, annotation or enum
+	Item // one element of a List, Array or Dict with its trailing comma
+	Param
+)
+
+var kindNames = [...]string{
+	File: "File", Raw: "Raw", Comment: "Comment", Annotation: "Annotation",
+	ClassName: "ClassName", Extends: "Extends", Signal: "Signal", Enum: "Enum",
+	EnumValue: "EnumValue", Const: "Const", Var: "Var", Accessor: "Accessor",
+	Func: "Func", Class: "Class", Suite: "Suite", If: "If", Elif: "Elif",
+	Else: "Else", For: "For", While: "While", Match: "Match", Branch: "Branch",
+	Pattern: "Pattern", Return: "Return", KeywordStmt: "KeywordStmt",
+	ExprStmt: "ExprStmt", Assign: "Assign", Name: "Name", Literal: "Literal",
+	Paren: "Paren", Unary: "Unary", Binary: "Binary", Ternary: "Ternary",
+	Call: "Call", Index: "Index", Attr: "Attr", Await: "Await", Array: "Array",
+	Dict: "Dict", Pair: "Pair", Lambda: "Lambda", List: "List", Item: "Item",
+	Param: "Param",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Invalid"
+}
+
+// Token is a lexer token together with the trivia in front of it: whitespace,
+// indentation, blank lines, line continuations and comments inside brackets.
+type Token struct {
+	lexer.Token
+	Leading string
+}
+
+func (t *Token) String() string {
+	return t.Leading + t.Text
+}
+
+// Element is a child of a Node, either a *Token or a *Node.
+type Element interface {
+	write(sb *strings.Builder)
+}
+
+func (t *Token) write(sb *strings.Builder) {
+	sb.WriteString(t.Leading)
+	sb.WriteString(t.Text)
+}
+
+// Node is a piece of syntax. Its children hold every token it was parsed
+// from in source order, so printing a tree gives back the exact source.
+type Node struct {
+	Kind     Kind
+	Children []Element
+}
+
+func (n *Node) write(sb *strings.Builder) {
+	for _, c := range n.Children {
+		c.write(sb)
+	}
+}
+
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.write(&sb)
+	return sb.String()
+}
+
+func (n *Node) add(children ...Element) {
+	n.Children = append(n.Children, children...)
+}
+
+// Tokens returns every token under the node in source order.
+func (n *Node) Tokens() []*Token {
+	var out []*Token
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *Token:
+			out = append(out, c)
+		case *Node:
+			out = append(out, c.Tokens()...)
+		}
+	}
+	return out
+}
+
+// Nodes returns the direct children that are nodes.
+func (n *Node) Nodes() []*Node {
+	var out []*Node
+	for _, c := range n.Children {
+		if c, ok := c.(*Node); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Child returns the first direct child of the given kind, or nil.
+func (n *Node) Child(kind Kind) *Node {
+	for _, c := range n.Nodes() {
+		if c.Kind == kind {
+			return c
+		}
+	}
+	return nil
+}
+
+// Keyword returns the first direct child token that is the given keyword, or nil.
+func (n *Node) Keyword(word string) *Token {
+	for _, c := range n.Children {
+		if t, ok := c.(*Token); ok && t.Is(lexer.Keyword, word) {
+			return t
+		}
+	}
+	return nil
+}
+
+// First returns the first token under the node, or nil for an empty node.
+func (n *Node) First() *Token {
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *Token:
+			return c
+		case *Node:
+			if t := c.First(); t != nil {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for n and every node below it, depth first. Returning false
+// from fn skips the children of that node.
+func Walk(n *Node, fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.Nodes() {
+		Walk(c, fn)
+	}
+}
@@ -0,0 +1,158 @@
+package cst
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sample = `@tool
+class_name Player extends CharacterBody2D
+## Player controller.
+
+signal hit(damage: int, source: Node = null)
+enum State { IDLE, RUN = 2, JUMP, }
+const SPEED := 300.0
+const TABLE = {
+"""key""": [1, 2,
+	3], # trailing
+}
+
+@export_range(0, 10) var lives: int = 3
+@onready var sprite: Sprite2D = $Sprite2D
+static var count = 0
+var health: int = 10:
+	set(value):
+		health = clamp(value, 0, 100)
+		hit.emit(health)
+	get:
+		return health
+var speed: float: set = set_speed, get = get_speed
+
+
+func _ready() -> void:
+	var callback = func(x): return x * 2
+	button.pressed.connect(func():
+		print("pressed")
+	)
+# unindented comment
+	if health > 0 and not is_dead: pass
+	elif health is not int:
+		return
+	else:
+		health -= 1; health += 1
+	for i: int in range(10):
+		continue
+	match state:
+		State.IDLE, State.RUN:
+			pass
+		[var a, ..] when a > 0:
+			pass
+		_: print("other")
+	var x = a if b else -c ** 2 as float
+	await get_tree().create_timer(1.0).timeout
+	%Unique.visible = x not in [1, 2] \
+		or y
+
+
+@rpc("any_peer", "call_local")
+static func make(a: Array[int], b := 2) -> Dictionary[String, int]:
+	return {a = 1, "b": &"name"}
+
+
+class Inner extends RefCounted:
+	var value = 0
+
+	func get_value():
+		return value
+`
+
+func TestParseRoundTrip(t *testing.T) {
+	inputs := []string{
+		sample,
+		"",
+		"\n\n",
+		"extends Node",
+		"var a = 1\n\n\n",
+		"func f():\n\tpass\n\n\n# end",
+		"func f():\n# only a comment\nvar b",
+		"this is not ( valid gdscript\n)\n\tweird:\n\t\tindent\n  back",
+		"var d = {\n\t# comment in brackets\n\t\"a\": 1,\n}\r\nvar e = 2\r\n",
+	}
+
+	for _, input := range inputs {
+		tree, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse returned error: %v\nInput:\n%s", err, input)
+		}
+		if got := tree.String(); got != input {
+			t.Errorf("Parse is not lossless.\nInput:\n%q\nGot:\n%q", input, got)
+		}
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	tree, err := Parse(sample)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	var members []Kind
+	for _, n := range tree.Nodes() {
+		members = append(members, n.Kind)
+	}
+	expected := []Kind{
+		Annotation, ClassName, Comment,
+		Signal, Enum, Const, Const,
+		Var, Var, Var, Var, Var,
+		Func, Annotation, Func, Class,
+	}
+	if !reflect.DeepEqual(members, expected) {
+		t.Errorf("Unexpected members.\nExpected:\n%v\nGot:\n%v", expected, members)
+	}
+
+	raw := 0
+	Walk(tree, func(n *Node) bool {
+		if n.Kind == Raw {
+			// Inline lambda bodies are the only raw parts.
+			raw++
+		}
+		return true
+	})
+	if raw != 2 {
+		t.Errorf("expected 2 raw lambda bodies, got %d", raw)
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tree, err := Parse("x = a or b and not c == d + e * -f ** g\n")
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	var shape func(n *Node) string
+	shape = func(n *Node) string {
+		switch n.Kind {
+		case Name, Literal:
+			return n.First().Text
+		}
+		out := "("
+		for i, c := range n.Children {
+			if i > 0 {
+				out += " "
+			}
+			switch c := c.(type) {
+			case *Token:
+				out += c.Text
+			case *Node:
+				out += shape(c)
+			}
+		}
+		return out + ")"
+	}
+
+	assign := tree.Nodes()[0]
+	expected := "(a or (b and (not (c == (d + (e * (- (f ** g))))))))"
+	if got := shape(assign.Nodes()[1]); got != expected {
+		t.Errorf("Unexpected precedence.\nExpected:\n%s\nGot:\n%s", expected, got)
+	}
+}
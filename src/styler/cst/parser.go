@@ -0,0 +1,873 @@
+package cst
+
+import (
+	"godot_linter/styler/lexer"
+)
+
+// Parse builds the concrete syntax tree of a GDScript source. Lines the
+// parser does not understand become Raw nodes, so only lexing errors fail.
+// The returned File always prints back to exactly src.
+func Parse(src string) (*Node, error) {
+	tokens, err := lexer.Lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, toks: filter(tokens)}
+	file := &Node{Kind: File}
+	for {
+		switch p.peek().Kind {
+		case lexer.EOF:
+			file.add(p.next())
+			return file, nil
+		case lexer.Dedent:
+			p.skip()
+		default:
+			file.add(p.statement())
+		}
+	}
+}
+
+// filter drops the tokens that only matter as trivia: the newlines of blank
+// lines and comments inside brackets.
+func filter(tokens []lexer.Token) []lexer.Token {
+	out := make([]lexer.Token, 0, len(tokens))
+	lineHasCode := false
+	for _, t := range tokens {
+		switch t.Kind {
+		case lexer.Newline:
+			if !lineHasCode {
+				continue
+			}
+			lineHasCode = false
+		case lexer.Comment:
+			if t.Depth > 0 {
+				continue
+			}
+			lineHasCode = true
+		case lexer.Indent, lexer.Dedent, lexer.EOF:
+		default:
+			lineHasCode = true
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// bailout unwinds a statement the parser cannot make sense of.
+type bailout struct{}
+
+type parser struct {
+	src     string
+	toks    []lexer.Token
+	pos     int
+	prevEnd int
+
+	suiteEnd int // position right after the last parsed suite
+}
+
+func (p *parser) peek() lexer.Token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(n int) lexer.Token {
+	if p.pos+n < len(p.toks) {
+		return p.toks[p.pos+n]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *parser) next() *Token {
+	t := p.toks[p.pos]
+	p.pos++
+	tok := &Token{Token: t, Leading: p.src[p.prevEnd:t.Offset]}
+	p.prevEnd = t.End()
+	return tok
+}
+
+// skip steps over a zero width Indent or Dedent token.
+func (p *parser) skip() {
+	p.pos++
+}
+
+func (p *parser) atPunct(text string) bool {
+	return p.peek().Is(lexer.Punctuation, text)
+}
+
+func (p *parser) atOp(text string) bool {
+	return p.peek().Is(lexer.Operator, text)
+}
+
+func (p *parser) atKeyword(text string) bool {
+	return p.peek().Is(lexer.Keyword, text)
+}
+
+// atLineEnd reports whether only a comment, if anything, is left on the line.
+func (p *parser) atLineEnd() bool {
+	switch p.peek().Kind {
+	case lexer.Newline, lexer.EOF:
+		return true
+	case lexer.Comment:
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind lexer.Kind) *Token {
+	if p.peek().Kind != kind {
+		panic(bailout{})
+	}
+	return p.next()
+}
+
+func (p *parser) expectPunct(text string) *Token {
+	if !p.atPunct(text) {
+		panic(bailout{})
+	}
+	return p.next()
+}
+
+func node(kind Kind, children ...Element) *Node {
+	return &Node{Kind: kind, Children: children}
+}
+
+// ---- Statements ----
+
+// statement parses one statement, falling back to a Raw node when it fails.
+func (p *parser) statement() (n *Node) {
+	pos, prevEnd := p.pos, p.prevEnd
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			p.pos, p.prevEnd = pos, prevEnd
+			n = p.raw()
+		}
+	}()
+
+	return p.parseStatement()
+}
+
+func (p *parser) parseStatement() *Node {
+	t := p.peek()
+	switch t.Kind {
+	case lexer.Comment:
+		n := node(Comment, p.next())
+		p.end(n)
+		return n
+	case lexer.Indent:
+		// Indentation without a header, keep it as a bare suite.
+		return p.suite()
+	case lexer.Annotation:
+		return p.annotated()
+	case lexer.Keyword:
+		switch t.Text {
+		case "class_name":
+			return p.classNameDecl()
+		case "extends":
+			n := node(Extends, p.next(), p.postfix())
+			p.end(n)
+			return n
+		case "signal":
+			return p.signalDecl()
+		case "enum":
+			return p.enumDecl()
+		case "const":
+			return p.constDecl()
+		case "var":
+			return p.varDecl(node(Var))
+		case "static":
+			n := node(Var, p.next())
+			switch {
+			case p.atKeyword("var"):
+				return p.varDecl(n)
+			case p.atKeyword("func"):
+				n.Kind = Func
+				return p.funcDecl(n)
+			}
+			panic(bailout{})
+		case "func":
+			if p.peekAt(1).Kind == lexer.Identifier {
+				return p.funcDecl(node(Func))
+			}
+		case "class":
+			return p.classDecl()
+		case "if":
+			return p.ifStmt()
+		case "for":
+			return p.forStmt()
+		case "while":
+			n := node(While, p.next(), p.expr(), p.expectPunct(":"))
+			p.block(n)
+			return n
+		case "match":
+			return p.matchStmt()
+		case "return":
+			n := node(Return, p.next())
+			if !p.atLineEnd() && !p.atPunct(";") {
+				n.add(p.expr())
+			}
+			p.end(n)
+			return n
+		case "pass", "break", "continue", "breakpoint":
+			n := node(KeywordStmt, p.next())
+			p.end(n)
+			return n
+		}
+	}
+
+	x := p.expr()
+	if t := p.peek(); t.Kind == lexer.Operator && isAssignment(t.Text) {
+		n := node(Assign, x, p.next(), p.expr())
+		p.end(n)
+		return n
+	}
+	n := node(ExprStmt, x)
+	p.end(n)
+	return n
+}
+
+// end finishes a simple statement with its `;`, trailing comment and newline.
+func (p *parser) end(n *Node) {
+	if p.atPunct(";") {
+		n.add(p.next())
+		if !p.atLineEnd() {
+			// Another statement follows on the same line.
+			return
+		}
+	}
+	if p.peek().Kind == lexer.Comment {
+		n.add(p.next())
+	}
+	switch p.peek().Kind {
+	case lexer.Newline:
+		n.add(p.next())
+	case lexer.EOF:
+	default:
+		if p.suiteEnd != p.pos {
+			panic(bailout{})
+		}
+	}
+}
+
+// raw consumes the rest of the line, and the indented suite below it when the
+// line ends with a colon.
+func (p *parser) raw() *Node {
+	n := node(Raw)
+	var last lexer.Token
+	for {
+		t := p.peek()
+		switch t.Kind {
+		case lexer.EOF, lexer.Indent, lexer.Dedent:
+			return n
+		case lexer.Newline:
+			n.add(p.next())
+			if last.Is(lexer.Punctuation, ":") && p.peek().Kind == lexer.Indent {
+				n.add(p.suite())
+			}
+			return n
+		case lexer.Comment:
+		default:
+			last = t
+		}
+		n.add(p.next())
+	}
+}
+
+// suite parses an indented run of statements up to its dedent.
+func (p *parser) suite() *Node {
+	if p.peek().Kind != lexer.Indent {
+		panic(bailout{})
+	}
+	p.skip()
+
+	n := node(Suite)
+	for {
+		switch p.peek().Kind {
+		case lexer.Dedent:
+			p.skip()
+			p.suiteEnd = p.pos
+			return n
+		case lexer.EOF:
+			p.suiteEnd = p.pos
+			return n
+		}
+		n.add(p.statement())
+	}
+}
+
+// block parses what follows the colon of a compound statement, either an
+// indented suite or statements on the same line.
+func (p *parser) block(n *Node) {
+	if p.atLineEnd() {
+		if p.peek().Kind == lexer.Comment {
+			n.add(p.next())
+		}
+		if p.peek().Kind == lexer.Newline {
+			n.add(p.next())
+		}
+		n.add(p.suite())
+		return
+	}
+
+	inline := node(Suite)
+	for {
+		stmt := p.parseStatement()
+		inline.add(stmt)
+		last := stmt.Children[len(stmt.Children)-1]
+		if t, ok := last.(*Token); !ok || !t.Is(lexer.Punctuation, ";") || p.atLineEnd() {
+			break
+		}
+	}
+	n.add(inline)
+}
+
+func (p *parser) annotation() *Node {
+	n := node(Annotation, p.next())
+	if p.atPunct("(") {
+		n.add(p.list(List, "(", ")", p.expr))
+	}
+	return n
+}
+
+func (p *parser) annotated() *Node {
+	var annotations []Element
+	for p.peek().Kind == lexer.Annotation {
+		annotations = append(annotations, p.annotation())
+	}
+
+	if p.atLineEnd() {
+		// Annotation on its own line, applying to whatever follows.
+		n := annotations[0].(*Node)
+		n.add(annotations[1:]...)
+		p.end(n)
+		return n
+	}
+
+	decl := p.parseStatement()
+	decl.Children = append(annotations, decl.Children...)
+	return decl
+}
+
+func (p *parser) classNameDecl() *Node {
+	n := node(ClassName, p.next(), p.expect(lexer.Identifier))
+	if p.atKeyword("extends") {
+		n.add(p.next(), p.postfix())
+	}
+	p.end(n)
+	return n
+}
+
+func (p *parser) classDecl() *Node {
+	n := node(Class, p.next(), p.expect(lexer.Identifier))
+	if p.atKeyword("extends") {
+		n.add(p.next(), p.postfix())
+	}
+	n.add(p.expectPunct(":"))
+	p.block(n)
+	return n
+}
+
+func (p *parser) signalDecl() *Node {
+	n := node(Signal, p.next(), p.expect(lexer.Identifier))
+	if p.atPunct("(") {
+		n.add(p.list(List, "(", ")", p.param))
+	}
+	p.end(n)
+	return n
+}
+
+func (p *parser) enumDecl() *Node {
+	n := node(Enum, p.next())
+	if p.peek().Kind == lexer.Identifier {
+		n.add(p.next())
+	}
+	n.add(p.list(List, "{", "}", func() *Node {
+		v := node(EnumValue, p.expect(lexer.Identifier))
+		if p.atOp("=") {
+			v.add(p.next(), p.expr())
+		}
+		return v
+	}))
+	p.end(n)
+	return n
+}
+
+func (p *parser) constDecl() *Node {
+	n := node(Const, p.next(), p.expect(lexer.Identifier))
+	if p.atPunct(":") {
+		n.add(p.next(), p.typeExpr())
+	}
+	if !p.atOp("=") && !p.atOp(":=") {
+		panic(bailout{})
+	}
+	n.add(p.next(), p.expr())
+	p.end(n)
+	return n
+}
+
+// varDecl parses a variable after any `static`, with its accessors.
+func (p *parser) varDecl(n *Node) *Node {
+	n.add(p.next(), p.expect(lexer.Identifier))
+
+	colonEndsLine := func() bool {
+		next := p.peekAt(1).Kind
+		return next == lexer.Newline || next == lexer.Comment
+	}
+
+	if p.atPunct(":") && !colonEndsLine() && !isAccessorName(p.peekAt(1)) {
+		n.add(p.next(), p.typeExpr())
+	}
+	if p.atOp("=") || p.atOp(":=") {
+		n.add(p.next(), p.expr())
+	}
+	if !p.atPunct(":") {
+		p.end(n)
+		return n
+	}
+
+	n.add(p.next())
+	if !p.atLineEnd() {
+		// Accessors on the same line, such as `var a: set = set_a`.
+		for !p.atLineEnd() {
+			n.add(p.accessor())
+			if p.atPunct(",") {
+				n.add(p.next())
+			}
+		}
+		p.end(n)
+		return n
+	}
+
+	if p.peek().Kind == lexer.Comment {
+		n.add(p.next())
+	}
+	n.add(p.expect(lexer.Newline))
+	if p.peek().Kind != lexer.Indent {
+		panic(bailout{})
+	}
+	p.skip()
+
+	body := node(Suite)
+	for {
+		switch t := p.peek(); {
+		case t.Kind == lexer.Dedent:
+			p.skip()
+			p.suiteEnd = p.pos
+			n.add(body)
+			return n
+		case t.Kind == lexer.EOF:
+			p.suiteEnd = p.pos
+			n.add(body)
+			return n
+		case t.Kind == lexer.Comment:
+			c := node(Comment, p.next())
+			p.end(c)
+			body.add(c)
+		default:
+			acc := p.accessor()
+			if !p.atPunct(",") {
+				p.end(acc)
+				body.add(acc)
+				continue
+			}
+			// Several accessors on one line, such as `get = get_a, set = set_a`.
+			line := node(Raw, acc)
+			for p.atPunct(",") {
+				line.add(p.next())
+				if !p.atLineEnd() {
+					line.add(p.accessor())
+				}
+			}
+			p.end(line)
+			body.add(line)
+		}
+	}
+}
+
+func isAccessorName(t lexer.Token) bool {
+	return t.Kind == lexer.Identifier && (t.Text == "get" || t.Text == "set")
+}
+
+// accessor parses `get:`, `set(value):` with their bodies, or `get = name`.
+func (p *parser) accessor() *Node {
+	if !isAccessorName(p.peek()) {
+		panic(bailout{})
+	}
+	n := node(Accessor, p.next())
+	if p.atOp("=") {
+		n.add(p.next(), p.expect(lexer.Identifier))
+		return n
+	}
+	if p.atPunct("(") {
+		n.add(p.list(List, "(", ")", p.param))
+	}
+	n.add(p.expectPunct(":"))
+	p.block(n)
+	return n
+}
+
+// funcDecl parses a function after any `static`.
+func (p *parser) funcDecl(n *Node) *Node {
+	n.add(p.next(), p.expect(lexer.Identifier), p.list(List, "(", ")", p.param))
+	if p.atOp("->") {
+		n.add(p.next(), p.typeExpr())
+	}
+	n.add(p.expectPunct(":"))
+	p.block(n)
+	return n
+}
+
+func (p *parser) param() *Node {
+	n := node(Param, p.expect(lexer.Identifier))
+	if p.atPunct(":") {
+		n.add(p.next(), p.typeExpr())
+	}
+	if p.atOp("=") || p.atOp(":=") {
+		n.add(p.next(), p.expr())
+	}
+	return n
+}
+
+func (p *parser) ifStmt() *Node {
+	n := node(If, p.next(), p.expr(), p.expectPunct(":"))
+	p.block(n)
+	for p.atKeyword("elif") {
+		clause := node(Elif, p.next(), p.expr(), p.expectPunct(":"))
+		p.block(clause)
+		n.add(clause)
+	}
+	if p.atKeyword("else") {
+		clause := node(Else, p.next(), p.expectPunct(":"))
+		p.block(clause)
+		n.add(clause)
+	}
+	return n
+}
+
+func (p *parser) forStmt() *Node {
+	n := node(For, p.next(), p.expect(lexer.Identifier))
+	if p.atPunct(":") {
+		n.add(p.next(), p.typeExpr())
+	}
+	if !p.atKeyword("in") {
+		panic(bailout{})
+	}
+	n.add(p.next(), p.expr(), p.expectPunct(":"))
+	p.block(n)
+	return n
+}
+
+func (p *parser) matchStmt() *Node {
+	n := node(Match, p.next(), p.expr(), p.expectPunct(":"))
+	if p.peek().Kind == lexer.Comment {
+		n.add(p.next())
+	}
+	n.add(p.expect(lexer.Newline))
+	if p.peek().Kind != lexer.Indent {
+		panic(bailout{})
+	}
+	p.skip()
+
+	branches := node(Suite)
+	for {
+		switch p.peek().Kind {
+		case lexer.Dedent:
+			p.skip()
+			p.suiteEnd = p.pos
+			n.add(branches)
+			return n
+		case lexer.EOF:
+			p.suiteEnd = p.pos
+			n.add(branches)
+			return n
+		case lexer.Comment:
+			c := node(Comment, p.next())
+			p.end(c)
+			branches.add(c)
+		default:
+			branches.add(p.branch())
+		}
+	}
+}
+
+// branch parses one match branch. Patterns are kept as tokens up to the
+// branch colon or `when` guard.
+func (p *parser) branch() *Node {
+	pattern := node(Pattern)
+	for !(p.peek().Depth == 0 && (p.atPunct(":") || p.atKeyword("when"))) {
+		if p.atLineEnd() {
+			panic(bailout{})
+		}
+		pattern.add(p.next())
+	}
+
+	n := node(Branch, pattern)
+	if p.atKeyword("when") {
+		n.add(p.next(), p.expr())
+	}
+	n.add(p.expectPunct(":"))
+	p.block(n)
+	return n
+}
+
+// ---- Expressions ----
+
+const (
+	precAs = iota + 1
+	precTernary
+	precOr
+	precAnd
+	precNot
+	precIn
+	precCompare
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precAdd
+	precMul
+	precSign
+	precBitNot
+	precPower
+	precIs
+)
+
+func isAssignment(op string) bool {
+	switch op {
+	case "=", "+=", "-=", "*=", "/=", "%=", "**=", "<<=", ">>=", "&=", "|=", "^=":
+		return true
+	}
+	return false
+}
+
+// infix returns the precedence of the binary operator at the current
+// position and how many tokens it spans, or 0 if there is none.
+func (p *parser) infix() (prec int, width int) {
+	t := p.peek()
+	switch t.Kind {
+	case lexer.Keyword:
+		switch t.Text {
+		case "as":
+			return precAs, 1
+		case "if":
+			return precTernary, 1
+		case "or":
+			return precOr, 1
+		case "and":
+			return precAnd, 1
+		case "in":
+			return precIn, 1
+		case "not":
+			if p.peekAt(1).Is(lexer.Keyword, "in") {
+				return precIn, 2
+			}
+		case "is":
+			if p.peekAt(1).Is(lexer.Keyword, "not") {
+				return precIs, 2
+			}
+			return precIs, 1
+		}
+	case lexer.Operator:
+		switch t.Text {
+		case "||":
+			return precOr, 1
+		case "&&":
+			return precAnd, 1
+		case "==", "!=", "<", ">", "<=", ">=":
+			return precCompare, 1
+		case "|":
+			return precBitOr, 1
+		case "^":
+			return precBitXor, 1
+		case "&":
+			return precBitAnd, 1
+		case "<<", ">>":
+			return precShift, 1
+		case "+", "-":
+			return precAdd, 1
+		case "*", "/", "%":
+			return precMul, 1
+		case "**":
+			return precPower, 1
+		}
+	}
+	return 0, 0
+}
+
+func (p *parser) expr() *Node {
+	return p.binary(0)
+}
+
+func (p *parser) binary(minPrec int) *Node {
+	left := p.unary()
+	for {
+		prec, width := p.infix()
+		if prec == 0 || prec <= minPrec {
+			return left
+		}
+
+		if prec == precTernary {
+			n := node(Ternary, left, p.next(), p.binary(precTernary))
+			if !p.atKeyword("else") {
+				panic(bailout{})
+			}
+			n.add(p.next(), p.binary(precTernary-1))
+			left = n
+			continue
+		}
+
+		n := node(Binary, left)
+		for range width {
+			n.add(p.next())
+		}
+		n.add(p.binary(prec))
+		left = n
+	}
+}
+
+func (p *parser) unary() *Node {
+	t := p.peek()
+	switch {
+	case t.Is(lexer.Keyword, "not"), t.Is(lexer.Operator, "!"):
+		return node(Unary, p.next(), p.binary(precNot-1))
+	case t.Is(lexer.Operator, "-"), t.Is(lexer.Operator, "+"):
+		return node(Unary, p.next(), p.binary(precSign))
+	case t.Is(lexer.Operator, "~"):
+		return node(Unary, p.next(), p.binary(precBitNot))
+	case t.Is(lexer.Keyword, "await"):
+		return node(Await, p.next(), p.unary())
+	}
+	return p.postfix()
+}
+
+// typeExpr parses a type hint such as `int`, `Node.Inner` or `Array[int]`.
+func (p *parser) typeExpr() *Node {
+	return p.postfix()
+}
+
+func (p *parser) postfix() *Node {
+	x := p.primary()
+	for {
+		switch {
+		case p.atPunct("."):
+			dot := p.next()
+			t := p.peek()
+			if t.Kind != lexer.Identifier && t.Kind != lexer.Keyword {
+				panic(bailout{})
+			}
+			x = node(Attr, x, dot, p.next())
+		case p.atPunct("("):
+			x = node(Call, x, p.list(List, "(", ")", p.expr))
+		case p.atPunct("["):
+			x = node(Index, x, p.list(List, "[", "]", p.expr))
+		default:
+			return x
+		}
+	}
+}
+
+func (p *parser) primary() *Node {
+	t := p.peek()
+	switch t.Kind {
+	case lexer.Identifier:
+		return node(Name, p.next())
+	case lexer.Number, lexer.String, lexer.StringName, lexer.NodePath:
+		return node(Literal, p.next())
+	case lexer.Keyword:
+		switch t.Text {
+		case "true", "false", "null":
+			return node(Literal, p.next())
+		case "self", "super", "void", "preload", "assert":
+			return node(Name, p.next())
+		case "func":
+			return p.lambda()
+		}
+	case lexer.Punctuation:
+		switch t.Text {
+		case "(":
+			return node(Paren, p.next(), p.expr(), p.expectPunct(")"))
+		case "[":
+			return p.list(Array, "[", "]", p.expr)
+		case "{":
+			return p.list(Dict, "{", "}", p.pair)
+		}
+	}
+	panic(bailout{})
+}
+
+func (p *parser) pair() *Node {
+	n := node(Pair, p.expr())
+	if !p.atPunct(":") && !p.atOp("=") {
+		panic(bailout{})
+	}
+	n.add(p.next(), p.expr())
+	return n
+}
+
+func (p *parser) lambda() *Node {
+	n := node(Lambda, p.next())
+	if p.peek().Kind == lexer.Identifier {
+		n.add(p.next())
+	}
+	n.add(p.list(List, "(", ")", p.param))
+	if p.atOp("->") {
+		n.add(p.next(), p.typeExpr())
+	}
+	n.add(p.expectPunct(":"))
+
+	if p.peek().Kind == lexer.Newline || p.peek().Kind == lexer.Comment {
+		// Statement level lambda with an indented body.
+		p.block(n)
+		return n
+	}
+
+	// Inline bodies, and bodies inside brackets where the lexer hides the
+	// layout, are kept as tokens up to the comma or bracket ending the lambda.
+	body := node(Raw)
+	nesting := 0
+	for {
+		t := p.peek()
+		if t.Kind == lexer.Newline || t.Kind == lexer.EOF {
+			break
+		}
+		if t.Kind == lexer.Punctuation {
+			switch t.Text {
+			case "(", "[", "{":
+				nesting++
+			case ")", "]", "}":
+				nesting--
+			case ",":
+				if nesting == 0 {
+					nesting = -1
+				}
+			}
+			if nesting < 0 {
+				break
+			}
+		}
+		body.add(p.next())
+	}
+	if len(body.Children) == 0 {
+		panic(bailout{})
+	}
+	n.add(body)
+	return n
+}
+
+// list parses a bracketed, comma separated list with an optional trailing comma.
+func (p *parser) list(kind Kind, open, close string, item func() *Node) *Node {
+	n := node(kind, p.expectPunct(open))
+	for !p.atPunct(close) {
+		it := node(Item, item())
+		if p.atPunct(",") {
+			it.add(p.next())
+		} else if !p.atPunct(close) {
+			panic(bailout{})
+		}
+		n.add(it)
+	}
+	n.add(p.next())
+	return n
+}
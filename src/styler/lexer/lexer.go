@@ -0,0 +1,550 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the lexical class of a Token.
+type Kind uint8
+
+const (
+	EOF Kind = iota
+	Newline
+	Indent
+	Dedent
+
+	Identifier
+	Keyword
+	Annotation
+	Number
+	String
+	StringName
+	NodePath
+	Operator
+	Punctuation
+	Comment
+
+	Invalid
+)
+
+// Token is a single lexeme of a GDScript source. Whitespace, line
+// continuations and newlines inside brackets are not tokens, they can be
+// recovered from the source between two tokens' offsets.
+type Token struct {
+	Kind   Kind
+	Text   string
+	Offset int // byte offset of Text in the source
+	Line   int // 1-based line of the first byte
+	Col    int // 1-based column (in runes) of the first byte
+	Depth  int // bracket depth the token sits at, openers and closers use the outer depth
+}
+
+// End returns the byte offset just past the token.
+func (t Token) End() int {
+	return t.Offset + len(t.Text)
+}
+
+// Is reports whether the token has the given kind and text.
+func (t Token) Is(kind Kind, text string) bool {
+	return t.Kind == kind && t.Text == text
+}
+
+// Error is a lexing problem at a position in the source.
+type Error struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// ErrorList is every Error found in a source, in order.
+type ErrorList []*Error
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return el[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", el[0], len(el)-1)
+}
+
+var keywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "await": true, "break": true,
+	"breakpoint": true, "class": true, "class_name": true, "const": true,
+	"continue": true, "elif": true, "else": true, "enum": true, "extends": true,
+	"false": true, "for": true, "func": true, "if": true, "in": true, "is": true,
+	"match": true, "namespace": true, "not": true, "null": true, "or": true,
+	"pass": true, "preload": true, "return": true, "self": true, "signal": true,
+	"static": true, "super": true, "trait": true, "true": true, "var": true,
+	"void": true, "when": true, "while": true, "yield": true,
+}
+
+// IsKeyword reports whether word is a reserved GDScript word.
+func IsKeyword(word string) bool {
+	return keywords[word]
+}
+
+// StringLines reports for each of n lines whether it starts inside a multi-line string of tokens,
+// such lines are part of the string's value and must not be re-indented.
+func StringLines(tokens []Token, n int) []bool {
+	inside := make([]bool, n)
+	for _, t := range tokens {
+		switch t.Kind {
+		case String, StringName, NodePath:
+			for l := t.Line; l < t.Line+strings.Count(t.Text, "\n") && l < n; l++ {
+				inside[l] = true
+			}
+		}
+	}
+	return inside
+}
+
+// Longest operators first so the scan is greedy.
+var operators = []string{
+	"**=", "<<=", ">>=",
+	"**", "<<", ">>", "==", "!=", "<=", ">=", "&&", "||",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", ":=", "->", "..",
+	"+", "-", "*", "/", "%", "<", ">", "=", "!", "~", "&", "|", "^",
+}
+
+const punctuation = "()[]{},:;."
+
+// IndentWidth is how many columns a tab counts for when comparing indentation.
+const IndentWidth = 4
+
+type position struct {
+	offset, line, col int
+}
+
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+	synced    int
+
+	indents []int
+	openers []Token
+	tokens  []Token
+	errs    ErrorList
+}
+
+// Lex splits src into tokens, always ending with Newline, any pending Dedents
+// and EOF. Problems such as unterminated strings or unbalanced brackets are
+// returned as an ErrorList alongside the tokens, the offending text is kept
+// as Invalid tokens so nothing from the source is dropped.
+func Lex(src string) ([]Token, error) {
+	l := &lexer{src: src, line: 1, indents: []int{0}}
+	l.run()
+
+	if len(l.errs) > 0 {
+		return l.tokens, l.errs
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) run() {
+	atLineStart := true
+
+	for l.pos < len(l.src) {
+		if atLineStart {
+			l.indentation()
+			atLineStart = false
+			continue
+		}
+
+		c := l.src[l.pos]
+		switch {
+		case c == '\n' || c == '\r' && l.peek(1) == '\n':
+			start := l.mark()
+			if c == '\r' {
+				l.pos++
+			}
+			l.pos++
+			if len(l.openers) == 0 {
+				l.emit(Newline, start)
+				atLineStart = true
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			l.pos++
+		case c == '\\':
+			start := l.mark()
+			l.pos++
+			switch {
+			case l.peek(0) == '\n':
+				l.pos++
+			case l.peek(0) == '\r' && l.peek(1) == '\n':
+				l.pos += 2
+			default:
+				l.emit(Invalid, start)
+				l.errorAt(start, "unexpected `\\` outside of a line continuation")
+			}
+		case c == '#':
+			start := l.mark()
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && !(l.src[l.pos] == '\r' && l.peek(1) == '\n') {
+				l.pos++
+			}
+			l.emit(Comment, start)
+		default:
+			l.token()
+		}
+	}
+
+	end := l.mark()
+	for _, open := range l.openers {
+		l.errs = append(l.errs, &Error{Line: open.Line, Col: open.Col, Msg: fmt.Sprintf("unclosed `%s`", open.Text)})
+	}
+	l.openers = nil
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].Kind != Newline {
+		l.emit(Newline, end)
+	}
+	for len(l.indents) > 1 {
+		l.indents = l.indents[:len(l.indents)-1]
+		l.emit(Dedent, end)
+	}
+	l.emit(EOF, end)
+}
+
+// indentation measures the leading whitespace of a line and emits Indent or
+// Dedent tokens for it. Blank and comment-only lines never change the level.
+func (l *lexer) indentation() {
+	width := 0
+	i := l.pos
+	for ; i < len(l.src); i++ {
+		if l.src[i] == '\t' {
+			width += IndentWidth
+		} else if l.src[i] == ' ' {
+			width++
+		} else {
+			break
+		}
+	}
+	l.pos = i
+
+	if i >= len(l.src) {
+		return
+	}
+	switch l.src[i] {
+	case '\n', '#':
+		return
+	case '\r':
+		if l.peek(1) == '\n' {
+			return
+		}
+	}
+
+	start := l.mark()
+	top := l.indents[len(l.indents)-1]
+	if width > top {
+		l.indents = append(l.indents, width)
+		l.emit(Indent, start)
+		return
+	}
+	for width < top {
+		l.indents = l.indents[:len(l.indents)-1]
+		l.emit(Dedent, start)
+		top = l.indents[len(l.indents)-1]
+	}
+	if width > top {
+		// Dedent to a level that was never opened, treat it as a new level.
+		l.indents = append(l.indents, width)
+		l.emit(Indent, start)
+	}
+}
+
+// token scans one significant token at the current position.
+func (l *lexer) token() {
+	start := l.mark()
+	c := l.src[l.pos]
+
+	switch {
+	case (c == 'r' || c == 'R') && isQuote(l.peek(1)):
+		l.pos++
+		l.emitString(String, start, true)
+	case c == '&' && isQuote(l.peek(1)):
+		l.pos++
+		l.emitString(StringName, start, false)
+	case c == '^' && isQuote(l.peek(1)):
+		l.pos++
+		l.emitString(NodePath, start, false)
+	case isQuote(c):
+		l.emitString(String, start, false)
+	case c == '$':
+		l.pos++
+		if isQuote(l.peek(0)) {
+			l.emitString(NodePath, start, false)
+			return
+		}
+		l.scanNodePath()
+		if l.pos == start.offset+1 {
+			l.emit(Invalid, start)
+			l.errorAt(start, "expected a node path after `$`")
+			return
+		}
+		l.emit(NodePath, start)
+	case c == '%' && !l.afterOperand() && (isQuote(l.peek(1)) || isIdentStart(l.runeAt(l.pos+1))):
+		l.pos++
+		if isQuote(l.peek(0)) {
+			l.emitString(NodePath, start, false)
+			return
+		}
+		l.scanNodePath()
+		l.emit(NodePath, start)
+	case c == '@':
+		l.pos++
+		if !isIdentStart(l.runeAt(l.pos)) {
+			l.emit(Invalid, start)
+			l.errorAt(start, "expected an annotation name after `@`")
+			return
+		}
+		l.scanIdent()
+		l.emit(Annotation, start)
+	case isDigit(c) || c == '.' && isDigit(l.peek(1)) && !l.afterOperand():
+		l.scanNumber()
+		l.emit(Number, start)
+	case isIdentStart(l.runeAt(l.pos)):
+		l.scanIdent()
+		kind := Identifier
+		if keywords[l.src[start.offset:l.pos]] {
+			kind = Keyword
+		}
+		l.emit(kind, start)
+	case strings.IndexByte(punctuation, c) >= 0 && !(c == '.' && l.peek(1) == '.') && !(c == ':' && l.peek(1) == '='):
+		l.pos++
+		l.bracket(start, c)
+	default:
+		for _, op := range operators {
+			if strings.HasPrefix(l.src[l.pos:], op) {
+				l.pos += len(op)
+				l.emit(Operator, start)
+				return
+			}
+		}
+		_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		l.pos += size
+		l.emit(Invalid, start)
+		l.errorAt(start, fmt.Sprintf("unexpected character %q", l.src[start.offset:l.pos]))
+	}
+}
+
+// bracket emits a punctuation token, keeping track of bracket depth.
+func (l *lexer) bracket(start position, c byte) {
+	switch c {
+	case '(', '[', '{':
+		l.emit(Punctuation, start)
+		l.openers = append(l.openers, l.tokens[len(l.tokens)-1])
+	case ')', ']', '}':
+		if len(l.openers) == 0 {
+			l.emit(Punctuation, start)
+			l.errorAt(start, fmt.Sprintf("unmatched `%c`", c))
+			return
+		}
+		open := l.openers[len(l.openers)-1]
+		l.openers = l.openers[:len(l.openers)-1]
+		l.emit(Punctuation, start)
+		if closerOf(open.Text[0]) != c {
+			l.errorAt(start, fmt.Sprintf("`%c` does not match `%s` at %d:%d", c, open.Text, open.Line, open.Col))
+		}
+	default:
+		l.emit(Punctuation, start)
+	}
+}
+
+// emitString scans a quoted string starting at the current position, which
+// must be on the opening quote, and emits it with the given kind.
+func (l *lexer) emitString(kind Kind, start position, raw bool) {
+	quote := l.src[l.pos]
+	triple := l.peek(1) == quote && l.peek(2) == quote
+	if triple {
+		l.pos += 3
+	} else {
+		l.pos++
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			// Raw strings keep the backslash but it still protects a quote.
+			if !raw || l.src[l.pos+1] == quote || l.src[l.pos+1] == '\\' {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+		case c == '\n' && !triple:
+			l.emit(Invalid, start)
+			l.errorAt(start, "unterminated string")
+			return
+		case c == quote && !triple:
+			l.pos++
+			l.emit(kind, start)
+			return
+		case c == quote && l.peek(1) == quote && l.peek(2) == quote:
+			l.pos += 3
+			// Quotes directly before the closer belong to the string.
+			for l.peek(0) == quote {
+				l.pos++
+			}
+			l.emit(kind, start)
+			return
+		default:
+			l.pos++
+		}
+	}
+
+	l.emit(Invalid, start)
+	l.errorAt(start, "unterminated string")
+}
+
+func (l *lexer) scanIdent() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIdentRune(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+// scanNodePath reads the names of an unquoted node path with their `/` and `%` separators, as in `$A/%B` or `%A/B`
+func (l *lexer) scanNodePath() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIdentRune(r) && r != '/' && r != '%' {
+			return
+		}
+		l.pos += size
+	}
+}
+
+func (l *lexer) scanNumber() {
+	if l.peek(0) == '0' && strings.IndexByte("xXbB", l.peek(1)) >= 0 {
+		l.pos += 2
+		for l.pos < len(l.src) && (isHexDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+		return
+	}
+
+	digits := func() {
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	}
+
+	digits()
+	if l.peek(0) == '.' && l.peek(1) != '.' && !isIdentStart(l.runeAt(l.pos+1)) {
+		l.pos++
+		digits()
+	}
+	if c := l.peek(0); c == 'e' || c == 'E' {
+		next := l.peek(1)
+		if isDigit(next) || (next == '+' || next == '-') && isDigit(l.peek(2)) {
+			l.pos += 2
+			digits()
+		}
+	}
+}
+
+// afterOperand reports whether the previous token ends an operand, which
+// makes a following `%` or `.` an operator rather than the start of a value.
+func (l *lexer) afterOperand() bool {
+	for i := len(l.tokens) - 1; i >= 0; i-- {
+		t := l.tokens[i]
+		switch t.Kind {
+		case Indent, Dedent, Comment:
+			continue
+		case Identifier, Number, String, StringName, NodePath:
+			return true
+		case Keyword:
+			return t.Text == "true" || t.Text == "false" || t.Text == "null" || t.Text == "self"
+		case Punctuation:
+			return t.Text == ")" || t.Text == "]" || t.Text == "}"
+		}
+		return false
+	}
+	return false
+}
+
+// mark returns the position of l.pos, updating the line bookkeeping first.
+func (l *lexer) mark() position {
+	for ; l.synced < l.pos; l.synced++ {
+		if l.src[l.synced] == '\n' {
+			l.line++
+			l.lineStart = l.synced + 1
+		}
+	}
+	return position{
+		offset: l.pos,
+		line:   l.line,
+		col:    utf8.RuneCountInString(l.src[l.lineStart:l.pos]) + 1,
+	}
+}
+
+func (l *lexer) emit(kind Kind, start position) {
+	l.tokens = append(l.tokens, Token{
+		Kind:   kind,
+		Text:   l.src[start.offset:l.pos],
+		Offset: start.offset,
+		Line:   start.line,
+		Col:    start.col,
+		Depth:  len(l.openers),
+	})
+}
+
+func (l *lexer) errorAt(p position, msg string) {
+	l.errs = append(l.errs, &Error{Line: p.line, Col: p.col, Msg: msg})
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+func (l *lexer) runeAt(i int) rune {
+	if i >= len(l.src) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[i:])
+	return r
+}
+
+func closerOf(open byte) byte {
+	switch open {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	}
+	return '}'
+}
+
+func isQuote(c byte) bool {
+	return c == '"' || c == '\''
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,164 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tok struct {
+	Kind Kind
+	Text string
+}
+
+func significant(tokens []Token) []tok {
+	var out []tok
+	for _, t := range tokens {
+		if t.Kind == EOF {
+			continue
+		}
+		out = append(out, tok{t.Kind, t.Text})
+	}
+	return out
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []tok
+	}{
+		{
+			name:  "Declaration with type hint",
+			input: "var speed: float = 1.5e3 # units",
+			expected: []tok{
+				{Keyword, "var"}, {Identifier, "speed"}, {Punctuation, ":"}, {Identifier, "float"},
+				{Operator, "="}, {Number, "1.5e3"}, {Comment, "# units"}, {Newline, ""},
+			},
+		},
+		{
+			name:  "String forms",
+			input: `x = [r"a\d", &"name", ^"path", $Node/Child, %Unique, 'q', """doc # not a comment"""]`,
+			expected: []tok{
+				{Identifier, "x"}, {Operator, "="}, {Punctuation, "["},
+				{String, `r"a\d"`}, {Punctuation, ","},
+				{StringName, `&"name"`}, {Punctuation, ","},
+				{NodePath, `^"path"`}, {Punctuation, ","},
+				{NodePath, "$Node/Child"}, {Punctuation, ","},
+				{NodePath, "%Unique"}, {Punctuation, ","},
+				{String, "'q'"}, {Punctuation, ","},
+				{String, `"""doc # not a comment"""`}, {Punctuation, "]"}, {Newline, ""},
+			},
+		},
+		{
+			name:  "Node paths through unique names",
+			input: "x = [%A/B, $A/%B, %A/%B]\n",
+			expected: []tok{
+				{Identifier, "x"}, {Operator, "="}, {Punctuation, "["},
+				{NodePath, "%A/B"}, {Punctuation, ","},
+				{NodePath, "$A/%B"}, {Punctuation, ","},
+				{NodePath, "%A/%B"}, {Punctuation, "]"}, {Newline, "\n"},
+			},
+		},
+		{
+			name:  "Modulo is not a node path",
+			input: "a % b\n",
+			expected: []tok{
+				{Identifier, "a"}, {Operator, "%"}, {Identifier, "b"}, {Newline, "\n"},
+			},
+		},
+		{
+			name:  "Indentation",
+			input: "func f():\n\tif x:\n\t\tpass\n\n# c\n\treturn\n",
+			expected: []tok{
+				{Keyword, "func"}, {Identifier, "f"}, {Punctuation, "("}, {Punctuation, ")"}, {Punctuation, ":"}, {Newline, "\n"},
+				{Indent, ""}, {Keyword, "if"}, {Identifier, "x"}, {Punctuation, ":"}, {Newline, "\n"},
+				{Indent, ""}, {Keyword, "pass"}, {Newline, "\n"},
+				{Newline, "\n"},
+				{Comment, "# c"}, {Newline, "\n"},
+				{Dedent, ""}, {Keyword, "return"}, {Newline, "\n"},
+				{Dedent, ""},
+			},
+		},
+		{
+			name:  "Brackets and continuations join lines",
+			input: "var d = {\n\"\"\"key\"\"\" : 0,\n}\nvar e = 1 + \\\n\t2\n",
+			expected: []tok{
+				{Keyword, "var"}, {Identifier, "d"}, {Operator, "="}, {Punctuation, "{"},
+				{String, `"""key"""`}, {Punctuation, ":"}, {Number, "0"}, {Punctuation, ","},
+				{Punctuation, "}"}, {Newline, "\n"},
+				{Keyword, "var"}, {Identifier, "e"}, {Operator, "="}, {Number, "1"}, {Operator, "+"},
+				{Number, "2"}, {Newline, "\n"},
+			},
+		},
+		{
+			name:  "Annotations and operators",
+			input: "@export_range(0, 10) var n := -1 ** 2 -> x",
+			expected: []tok{
+				{Annotation, "@export_range"}, {Punctuation, "("}, {Number, "0"}, {Punctuation, ","},
+				{Number, "10"}, {Punctuation, ")"}, {Keyword, "var"}, {Identifier, "n"}, {Operator, ":="},
+				{Operator, "-"}, {Number, "1"}, {Operator, "**"}, {Number, "2"}, {Operator, "->"},
+				{Identifier, "x"}, {Newline, ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex returned error: %v", err)
+			}
+			actual := significant(tokens)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Lex failed.\nInput:\n%q\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLexPositions(t *testing.T) {
+	tokens, _ := Lex("a = \"\"\"x\ny\"\"\"\n\tb(\n\t\tc)\n")
+
+	expected := map[string][3]int{
+		"a": {1, 1, 0},
+		"b": {3, 2, 0},
+		"c": {4, 3, 1},
+		")": {4, 4, 0},
+	}
+	for _, tok := range tokens {
+		want, ok := expected[tok.Text]
+		if !ok {
+			continue
+		}
+		if got := [3]int{tok.Line, tok.Col, tok.Depth}; got != want {
+			t.Errorf("token %q at line/col/depth %v, expected %v", tok.Text, got, want)
+		}
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		col   int
+	}{
+		{"Unterminated string", "var a = \"oops\nvar b", 1, 9},
+		{"Unclosed bracket", "var a = [1,\n2", 1, 9},
+		{"Unmatched closer", "x)\n", 1, 2},
+		{"Stray character", "var a = `b`", 1, 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Lex(tt.input)
+			errs, ok := err.(ErrorList)
+			if !ok || len(errs) == 0 {
+				t.Fatalf("expected an ErrorList, got %v", err)
+			}
+			if errs[0].Line != tt.line || errs[0].Col != tt.col {
+				t.Errorf("error at %d:%d, expected %d:%d (%v)", errs[0].Line, errs[0].Col, tt.line, tt.col, errs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Package migrate rewrites Godot 3 GDScript syntax to Godot 4.
+package migrate
+
+import (
+	"slices"
+	"strings"
+
+	"godot_linter/styler/lexer"
+)
+
+// edit replaces src[start:end] with text
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Godot3 rewrites the Godot 3 forms in src to Godot 4:
+// `tool`, `onready var`, `export var` and `export(...)` hints, `setget` and `yield(obj, "signal")`.
+// Forms it can't rewrite are left as they are, so the tokeniser reports them.
+func Godot3(src string) string {
+	tokens, err := lexer.Lex(src)
+	if err != nil {
+		return src
+	}
+
+	var edits []edit
+	for _, line := range logicalLines(tokens) {
+		edits = append(edits, migrateLine(src, line.tokens, line.level)...)
+	}
+
+	slices.SortFunc(edits, func(a, b edit) int { return b.start - a.start })
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+	return src
+}
+
+type logicalLine struct {
+	tokens []lexer.Token
+	level  int // indent level
+}
+
+// logicalLines groups the significant tokens by logical line
+func logicalLines(tokens []lexer.Token) []logicalLine {
+	var lines []logicalLine
+	cur := logicalLine{}
+	for _, t := range tokens {
+		switch t.Kind {
+		case lexer.Indent:
+			cur.level++
+		case lexer.Dedent:
+			cur.level--
+		case lexer.Newline, lexer.EOF:
+			if len(cur.tokens) > 0 {
+				lines = append(lines, cur)
+			}
+			cur = logicalLine{level: cur.level}
+		default:
+			cur.tokens = append(cur.tokens, t)
+		}
+	}
+	return lines
+}
+
+func migrateLine(src string, toks []lexer.Token, level int) []edit {
+	var edits []edit
+
+	first := toks[0]
+	switch {
+	case level == 0 && len(toks) == 1 && first.Is(lexer.Identifier, "tool"):
+		edits = append(edits, replace(first, first, "@tool"))
+	case len(toks) > 1 && first.Is(lexer.Identifier, "onready") && toks[1].Is(lexer.Keyword, "var"):
+		edits = append(edits, replace(first, first, "@onready"))
+	case len(toks) > 1 && first.Is(lexer.Identifier, "export"):
+		edits = append(edits, migrateExport(src, toks)...)
+	}
+
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.Is(lexer.Identifier, "setget") && i > 0 && slices.ContainsFunc(toks[:i], isVar):
+			if e, ok := migrateSetget(toks, i); ok {
+				edits = append(edits, e)
+			}
+		case t.Is(lexer.Keyword, "yield") && i+1 < len(toks) && toks[i+1].Is(lexer.Punctuation, "("):
+			close := matching(toks, i+1)
+			if close < 0 {
+				continue
+			}
+			if e, ok := migrateYield(src, toks, i, close); ok {
+				edits = append(edits, e)
+			}
+			// Yields in the arguments are rewritten with it or not at all
+			i = close
+		}
+	}
+	return edits
+}
+
+func isVar(t lexer.Token) bool {
+	return t.Is(lexer.Keyword, "var")
+}
+
+func replace(from, to lexer.Token, text string) edit {
+	return edit{start: from.Offset, end: to.End(), text: text}
+}
+
+// matching returns the index of the bracket closing the one at open, or -1
+func matching(toks []lexer.Token, open int) int {
+	closer := map[string]string{"(": ")", "[": "]", "{": "}"}[toks[open].Text]
+	for i := open + 1; i < len(toks); i++ {
+		if toks[i].Depth == toks[open].Depth && toks[i].Is(lexer.Punctuation, closer) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitArgs splits the tokens between the brackets at open and close on top level commas
+func splitArgs(toks []lexer.Token, open, close int) [][]lexer.Token {
+	var args [][]lexer.Token
+	var cur []lexer.Token
+	for _, t := range toks[open+1 : close] {
+		if t.Kind == lexer.Comment {
+			continue
+		}
+		if t.Depth == toks[open].Depth+1 && t.Is(lexer.Punctuation, ",") {
+			args = append(args, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		args = append(args, cur)
+	}
+	return args
+}
+
+func text(src string, toks []lexer.Token) string {
+	return src[toks[0].Offset:toks[len(toks)-1].End()]
+}
+
+// migrateYield turns `yield(obj, "signal")` into `await obj.signal`, waiting on "completed" becomes `await obj`
+func migrateYield(src string, toks []lexer.Token, at, close int) (edit, bool) {
+	args := splitArgs(toks, at+1, close)
+	if len(args) != 2 || len(args[0]) == 0 || len(args[1]) != 1 || args[1][0].Kind != lexer.String {
+		return edit{}, false
+	}
+
+	signal := unquote(args[1][0].Text)
+	if !isIdentifier(signal) {
+		return edit{}, false
+	}
+
+	obj := text(src, args[0])
+	if needsParens(args[0]) {
+		obj = "(" + obj + ")"
+	}
+	if signal == "completed" {
+		// Waiting for a function state is waiting for the coroutine
+		return replace(toks[at], toks[close], "await "+obj), true
+	}
+	return replace(toks[at], toks[close], "await "+obj+"."+signal), true
+}
+
+// needsParens reports whether an expression has top level operators that would bind looser than `.`
+func needsParens(expr []lexer.Token) bool {
+	for _, t := range expr {
+		if t.Depth != expr[0].Depth {
+			continue
+		}
+		switch {
+		case t.Kind == lexer.Operator:
+			return true
+		case t.Kind == lexer.Keyword && slices.Contains([]string{"and", "or", "not", "in", "is", "as", "if", "else", "await"}, t.Text):
+			return true
+		}
+	}
+	return false
+}
+
+// migrateSetget turns `setget setter, getter` at toks[at] into `: set = setter, get = getter`
+func migrateSetget(toks []lexer.Token, at int) (edit, bool) {
+	var setter, getter string
+	i := at + 1
+	if i < len(toks) && toks[i].Kind == lexer.Identifier {
+		setter = toks[i].Text
+		i++
+	}
+	if i < len(toks) && toks[i].Is(lexer.Punctuation, ",") {
+		if i+1 >= len(toks) || toks[i+1].Kind != lexer.Identifier {
+			return edit{}, false
+		}
+		getter = toks[i+1].Text
+		i += 2
+	}
+	if i < len(toks) && toks[i].Kind != lexer.Comment || setter == "" && getter == "" {
+		return edit{}, false
+	}
+
+	var parts []string
+	if setter != "" {
+		parts = append(parts, "set = "+setter)
+	}
+	if getter != "" {
+		parts = append(parts, "get = "+getter)
+	}
+	return edit{start: toks[at-1].End(), end: toks[i-1].End(), text: ": " + strings.Join(parts, ", ")}, true
+}
+
+// migrateExport turns `export var` and `export(hint) var` into the matching Godot 4 annotation,
+// the type of the hint is added to the variable if it has none
+func migrateExport(src string, toks []lexer.Token) []edit {
+	if toks[1].Is(lexer.Keyword, "var") {
+		return []edit{replace(toks[0], toks[0], "@export")}
+	}
+	if !toks[1].Is(lexer.Punctuation, "(") {
+		return nil
+	}
+
+	close := matching(toks, 1)
+	if close < 0 || close+2 >= len(toks) || !isVar(toks[close+1]) || toks[close+2].Kind != lexer.Identifier {
+		return nil
+	}
+
+	annotation, typ, ok := exportHint(src, splitArgs(toks, 1, close))
+	if !ok {
+		return nil
+	}
+	edits := []edit{replace(toks[0], toks[close], annotation)}
+
+	name := toks[close+2]
+	var next lexer.Token
+	if close+3 < len(toks) {
+		next = toks[close+3]
+	}
+	switch {
+	case typ == "" || next.Is(lexer.Punctuation, ":"):
+		// Already typed
+	case next.Is(lexer.Operator, ":="):
+		edits = append(edits, edit{start: name.End(), end: next.End(), text: ": " + typ + " ="})
+	default:
+		edits = append(edits, edit{start: name.End(), end: name.End(), text: ": " + typ})
+	}
+	return edits
+}
+
+// Godot 3 layer hints of int exports and their Godot 4 annotations
+var layerHints = map[string]string{
+	"LAYERS_2D_PHYSICS":    "@export_flags_2d_physics",
+	"LAYERS_2D_RENDER":     "@export_flags_2d_render",
+	"LAYERS_2D_NAVIGATION": "@export_flags_2d_navigation",
+	"LAYERS_3D_PHYSICS":    "@export_flags_3d_physics",
+	"LAYERS_3D_RENDER":     "@export_flags_3d_render",
+	"LAYERS_3D_NAVIGATION": "@export_flags_3d_navigation",
+}
+
+// exportHint returns the annotation and variable type for the arguments of a Godot 3 `export(...)`
+func exportHint(src string, args [][]lexer.Token) (annotation string, typ string, ok bool) {
+	if len(args) == 0 {
+		return "@export", "", true
+	}
+
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		texts[i] = text(src, arg)
+	}
+	typ, rest := texts[0], texts[1:]
+	hint := ""
+	if len(rest) > 0 {
+		hint = rest[0]
+	}
+
+	switch {
+	case len(rest) == 0:
+		return "@export", typ, true
+	case typ == "Array" && len(rest) == 1:
+		return "@export", "Array[" + rest[0] + "]", true
+	case typ == "String" && hint == "FILE":
+		return "@export_file" + call(rest[1:]), typ, true
+	case typ == "String" && hint == "DIR" && len(rest) == 1:
+		return "@export_dir", typ, true
+	case typ == "String" && hint == "GLOBAL" && len(rest) > 1 && rest[1] == "FILE":
+		return "@export_global_file" + call(rest[2:]), typ, true
+	case typ == "String" && hint == "GLOBAL" && len(rest) == 2 && rest[1] == "DIR":
+		return "@export_global_dir", typ, true
+	case typ == "String" && hint == "MULTILINE" && len(rest) == 1:
+		return "@export_multiline", typ, true
+	case (typ == "String" || typ == "int") && all(args[1:], isString):
+		return "@export_enum" + call(rest), typ, true
+	case typ == "int" && hint == "FLAGS" && len(rest) > 1:
+		return "@export_flags" + call(rest[1:]), typ, true
+	case typ == "int" && layerHints[hint] != "" && len(rest) == 1:
+		return layerHints[hint], typ, true
+	case typ == "float" && hint == "EXP" && (len(rest) == 3 || len(rest) == 4) && all(args[2:], isNumber):
+		return "@export_range" + call(append(slices.Clone(rest[1:]), `"exp"`)), typ, true
+	case typ == "float" && hint == "EASE" && len(rest) == 1:
+		return "@export_exp_easing", typ, true
+	case (typ == "int" || typ == "float") && len(rest) <= 3 && all(args[1:], isNumber):
+		return "@export_range" + call(rest), typ, true
+	case typ == "Color" && hint == "RGB" && len(rest) == 1:
+		return "@export_color_no_alpha", typ, true
+	}
+	return "", "", false
+}
+
+func call(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+func all(args [][]lexer.Token, fn func([]lexer.Token) bool) bool {
+	for _, arg := range args {
+		if !fn(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+func isString(arg []lexer.Token) bool {
+	return len(arg) == 1 && arg[0].Kind == lexer.String
+}
+
+// isNumber reports whether arg is a number literal with an optional sign
+func isNumber(arg []lexer.Token) bool {
+	if len(arg) == 2 && (arg[0].Is(lexer.Operator, "-") || arg[0].Is(lexer.Operator, "+")) {
+		arg = arg[1:]
+	}
+	return len(arg) == 1 && arg[0].Kind == lexer.Number
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || lexer.IsKeyword(s) {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
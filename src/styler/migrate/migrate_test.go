@@ -0,0 +1,71 @@
+package migrate
+
+import "testing"
+
+func TestGodot3(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Tool",
+			input:    "tool\nextends Node",
+			expected: "@tool\nextends Node",
+		},
+		{
+			name:     "Onready and plain export",
+			input:    "onready var a = $A\nexport var b = 1",
+			expected: "@onready var a = $A\n@export var b = 1",
+		},
+		{
+			name:     "Export type",
+			input:    "export(PackedScene) var scene\nexport(int) var count := 3\nexport(float) var speed: float = 1.0",
+			expected: "@export var scene: PackedScene\n@export var count: int = 3\n@export var speed: float = 1.0",
+		},
+		{
+			name:     "Export ranges",
+			input:    "export(int, 0, 10) var a = 5\nexport(float, -1.0, 1.0, 0.1) var b\nexport(float, EXP, 1, 100) var c",
+			expected: "@export_range(0, 10) var a: int = 5\n@export_range(-1.0, 1.0, 0.1) var b: float\n@export_range(1, 100, \"exp\") var c: float",
+		},
+		{
+			name:     "Export string hints",
+			input:    "export(String, FILE, \"*.json\") var a\nexport(String, GLOBAL, DIR) var b\nexport(String, MULTILINE) var c\nexport(String, \"x\", \"y\") var d",
+			expected: "@export_file(\"*.json\") var a: String\n@export_global_dir var b: String\n@export_multiline var c: String\n@export_enum(\"x\", \"y\") var d: String",
+		},
+		{
+			name:     "Export flags, arrays and colors",
+			input:    "export(int, FLAGS, \"Fire\", \"Water\") var a\nexport(int, LAYERS_3D_RENDER) var b\nexport(Array, String) var c\nexport(Color, RGB) var d",
+			expected: "@export_flags(\"Fire\", \"Water\") var a: int\n@export_flags_3d_render var b: int\n@export var c: Array[String]\n@export_color_no_alpha var d: Color",
+		},
+		{
+			name:     "Unsupported export hint is left",
+			input:    "export(Dictionary, 1) var a",
+			expected: "export(Dictionary, 1) var a",
+		},
+		{
+			name:     "Setget",
+			input:    "var a = 1 setget set_a, get_a # keep\nvar b setget set_b\nvar c: int setget , get_c",
+			expected: "var a = 1: set = set_a, get = get_a # keep\nvar b: set = set_b\nvar c: int: get = get_c",
+		},
+		{
+			name:     "Yield",
+			input:    "func f():\n\tyield(get_tree().create_timer(1.0), \"timeout\")\n\tvar r = yield(load_level(), \"completed\")\n\tyield(a if b else c, 'done')",
+			expected: "func f():\n\tawait get_tree().create_timer(1.0).timeout\n\tvar r = await load_level()\n\tawait (a if b else c).done",
+		},
+		{
+			name:     "Godot 4 code is unchanged",
+			input:    "@tool\nextends Node\n@export var a: int = 1\nvar tool = 2\nfunc f():\n\ttool\n\tawait done",
+			expected: "@tool\nextends Node\n@export var a: int = 1\nvar tool = 2\nfunc f():\n\ttool\n\tawait done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Godot3(tt.input)
+			if actual != tt.expected {
+				t.Errorf("Godot3 failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, actual)
+			}
+		})
+	}
+}
@@ -4,77 +4,121 @@ package styler
 // Block exports and onreadys and local vars in the tokeniser
 
 import (
+	"errors"
 	"fmt"
+	"godot_linter/config"
+	"godot_linter/diff"
+	"godot_linter/format"
 	"godot_linter/printer"
+	"io"
 	"os"
-	"slices"
+	"path/filepath"
 	"strings"
 
 	tk "godot_linter/styler/tokendef"
-	"godot_linter/styler/tokeniser"
 )
 
 type TokenizerError struct {
 	FilePath string
 	Message  string
+	// Lines that couldn't be tokenised
+	Lines []format.LineError
 }
 
 func (terr TokenizerError) Error() string {
 	return fmt.Sprintf("Error tokenising file %s: %s", terr.FilePath, terr.Message)
 }
 
-func LintFile(path string, ch chan error, verbose bool, dry bool) {
-	if verbose {
+// NotFormattedError is reported in check mode for files that would be changed
+type NotFormattedError struct {
+	FilePath string
+}
+
+func (nerr NotFormattedError) Error() string {
+	return fmt.Sprintf("File is not formatted: %s", nerr.FilePath)
+}
+
+// VerifyError is reported when the formatted file isn't a reordering of the original, the file is left as is
+type VerifyError struct {
+	FilePath string
+	Message  string
+}
+
+func (verr VerifyError) Error() string {
+	return fmt.Sprintf("Refusing to format %s, the result would change its code: %s", verr.FilePath, verr.Message)
+}
+
+// IdempotencyError is reported in idempotency mode for files a second pass would change
+type IdempotencyError struct {
+	FilePath string
+	Message  string
+}
+
+func (ierr IdempotencyError) Error() string {
+	return fmt.Sprintf("Formatting isn't idempotent for %s: %s", ierr.FilePath, ierr.Message)
+}
+
+type Options struct {
+	Config  config.Config
+	Verbose bool
+	// Don't write changes
+	Dry bool
+	// Don't write changes, report files that would change as NotFormattedError
+	Check bool
+	// Don't write changes, print a unified diff of them
+	Diff bool
+	// Don't write changes, format twice and report a changed second pass as IdempotencyError
+	VerifyIdempotent bool
+	// Rewrite Godot 3 syntax to Godot 4
+	MigrateGodot3 bool
+}
+
+func (opts Options) format() format.Options {
+	return format.Options{Config: opts.Config, MigrateGodot3: opts.MigrateGodot3}
+}
+
+func LintFile(path string, ch chan error, opts Options) {
+	if opts.Verbose {
 		printer.PrintNormal("Linting " + path)
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		ch <- err
+		return
 	}
 
-	lines := strings.Split(string(data), "\n")
+	if opts.VerifyIdempotent {
+		err = format.VerifyIdempotent(data, opts.format())
+		if err != nil {
+			ch <- fileError(err, path)
+		}
+		return
+	}
 
-	tokens, err := tokeniser.Tokenize(lines)
+	det, err := lint(string(data), path, opts)
 	if err != nil {
-		terr := TokenizerError{FilePath: path, Message: err.Error()}
-		ch <- terr
+		ch <- err
 		return
 	}
 
-	if verbose {
-		// Print before changes
-		println("<== Tokenisation")
-		for _, t := range tokens {
-			print(tk.BlockTypeToString(t.Type) + ":\n")
-			printer.PPrintArray(t.Content)
-		}
+	if opts.Diff {
+		printDiff(path, string(data), det)
 	}
 
-	// Sort blocks by enum order
-	slices.SortStableFunc(tokens, func(a, b tk.Block) int {
-		return int(a.Type) - int(b.Type)
-	})
-
-	if verbose {
-		// After
-		println("<== Tokens after sort")
-		for _, t := range tokens {
-			print(tk.BlockTypeToString(t.Type) + ":\n")
-			printer.PPrintArray(t.Content)
+	if opts.Check {
+		if det != string(data) {
+			ch <- NotFormattedError{FilePath: path}
 		}
+		return
 	}
 
-	det := Detokenise(tokens)
-
-	if verbose {
-		// After
-		println("<== Final")
-		print(det + "\n")
+	if opts.Diff {
+		return
 	}
 
 	// Write edited file
-	if !dry {
+	if !opts.Dry {
 		err = os.WriteFile(path, []byte(det), 0644)
 		if err != nil {
 			ch <- err
@@ -84,40 +128,101 @@ func LintFile(path string, ch chan error, verbose bool, dry bool) {
 	printer.PrintSuccess("Finished: " + path)
 }
 
-// Order parts
-//	Extends
-//	Exports
-//	Onready
-//	Local vars
-//	Functions
-
-// Double space functions, 2 spaces from last thing above
+// LintStream formats the source read from r and writes it to w, name is only used in errors and diffs.
+// In check, diff and idempotency mode nothing is written to w.
+func LintStream(r io.Reader, w io.Writer, name string, opts Options) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
 
-// Remove default comments
+	if opts.VerifyIdempotent {
+		if err := format.VerifyIdempotent(data, opts.format()); err != nil {
+			return fileError(err, name)
+		}
+		return nil
+	}
 
-// Add return typing
+	det, err := lint(string(data), name, opts)
+	if err != nil {
+		return err
+	}
 
-func Detokenise(tokens []tk.Block) string {
-	file := ""
-	for i, token := range tokens {
-		file += strings.Join(token.Content, "\n")
+	if opts.Diff {
+		printDiff(name, string(data), det)
+	}
 
-		if i+1 == len(tokens) {
-			break
+	if opts.Check {
+		if det != string(data) {
+			return NotFormattedError{FilePath: name}
 		}
+		return nil
+	}
 
-		// Start with 1 newline
-		newlines := 2
+	if opts.Diff {
+		return nil
+	}
+
+	_, err = io.WriteString(w, det)
+	return err
+}
 
-		switch token.Type {
-		case tk.ClassName:
-			newlines--
-		case tk.Function, tk.Ready, tk.Init:
-			newlines++
+// lint formats the source, printing each stage in verbose mode
+func lint(data string, path string, opts Options) (string, error) {
+	fopts := opts.format()
+	if opts.Verbose {
+		fopts.Trace = func(stage string, blocks []tk.Block) {
+			println("<== Blocks " + stage)
+			for _, t := range blocks {
+				print(tk.BlockTypeToString(t.Type) + ":\n")
+				printer.PPrintArray(t.Content)
+			}
 		}
+	}
+
+	det, err := format.Format([]byte(data), fopts)
+	if err != nil {
+		return "", fileError(err, path)
+	}
 
-		file += strings.Repeat("\n", newlines)
+	if opts.Verbose {
+		println("<== Final")
+		print(string(det) + "\n")
+	}
+
+	return string(det), nil
+}
+
+// fileError turns an error of the format package into the matching error for the file at path
+func fileError(err error, path string) error {
+	var terr *format.TokenizeError
+	var verr *format.VerifyError
+	var ierr *format.IdempotencyError
+	switch {
+	case errors.As(err, &terr):
+		return TokenizerError{FilePath: path, Message: terr.Message, Lines: terr.Lines}
+	case errors.As(err, &verr):
+		return VerifyError{FilePath: path, Message: verr.Message}
+	case errors.As(err, &ierr):
+		return IdempotencyError{FilePath: path, Message: ierr.Error()}
 	}
+	return err
+}
 
-	return file
+func printDiff(path string, before string, after string) {
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	printer.PrintDiff(diff.Unified("a/"+name, "b/"+name, before, after))
 }
+
+// Order parts
+//	Extends
+//	Exports
+//	Onready
+//	Local vars
+//	Functions
+
+// Double space functions, 2 spaces from last thing above
+
+// Remove default comments
+
+// Add return typing
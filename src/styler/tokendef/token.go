@@ -1,58 +1,107 @@
 package tokendef
 
+import "strings"
+
 type BlockType int8
 
 const (
 	Tool BlockType = iota
+	ClassAnnotation
 	ClassName
 	Extend
+	ClassDoc
 	DocString
 
 	Signals
 	Enum
 	Constants
+	StaticVar
 	Export
 	Onready
 	Class
 	LocalVar
 
-	Init
-	Ready
+	StaticFunction
+	Virtual
 	Function
+	PrivateFunction
 	Unknown
+
+	// Comments below the last member, always kept last so not part of the configurable order
+	EndComments
+	// Members between `#region` and `#endregion`, ranked as its first member
+	Region
+	// A line that applies to the lines below it, like `@warning_ignore_start`, never moved and never crossed by members
+	Positional
 )
 
-var Prefixes = []string{
-	"@tool",
-	"class_name",
-	"extends",
-	"\"\"\"",
-	"signal",
-	"enum",
-	"const",
-	"@export",
-	"@onready",
-	"class ",
-	"var",
-	"static var",
-	"func ",
-	"static func ",
-	"#",
+// VirtualMethods are the engine callbacks in the order the style guide lists them,
+// a Virtual block's Order is its index here
+var VirtualMethods = []string{
+	"_init",
+	"_enter_tree",
+	"_ready",
+	"_process",
+	"_physics_process",
+	"_exit_tree",
+	"_input",
+	"_shortcut_input",
+	"_unhandled_input",
+	"_unhandled_key_input",
+	"_gui_input",
+	"_notification",
+	"_draw",
+	"_integrate_forces",
+	"_get",
+	"_set",
+	"_get_property_list",
+	"_property_can_revert",
+	"_property_get_revert",
+	"_validate_property",
+	"_get_configuration_warnings",
+	"_can_drop_data",
+	"_drop_data",
+	"_get_drag_data",
+	"_has_point",
+	"_make_custom_tooltip",
+	"_to_string",
+}
+
+// Property is a variable declaration with an indented get/set accessor block below it,
+// Start and End are the lines of the declaration and the end of its accessors in a block's Content
+type Property struct {
+	Start, End int
 }
 
 type Block struct {
 	Type    BlockType
 	Content []string
+
+	// Declarations in Content with an accessor block
+	Properties []Property
+
+	// Position within blocks of the same type, used for virtual methods
+	Order int
+
+	// Members of an inner class, tokenised with the class indent stripped, or of a region
+	Children []Block
+
+	// Lines after the children, the `#endregion` of a region
+	End []string
 }
 
 func BlockTypeToString(bt BlockType) string {
 	switch bt {
 	case Tool:
 		return "Tool"
+	case ClassAnnotation:
+		return "ClassAnnotation"
 	case ClassName:
 		return "ClassName"
 	case Extend:
 		return "Extend"
+	case ClassDoc:
+		return "ClassDoc"
 	case DocString:
 		return "DocString"
 	case Signals:
@@ -61,6 +110,8 @@ func BlockTypeToString(bt BlockType) string {
 		return "Enum"
 	case Constants:
 		return "Constants"
+	case StaticVar:
+		return "StaticVar"
 	case Export:
 		return "Export"
 	case Onready:
@@ -69,15 +120,34 @@ func BlockTypeToString(bt BlockType) string {
 		return "Class"
 	case LocalVar:
 		return "LocalVar"
-	case Init:
-		return "Init"
-	case Ready:
-		return "Ready"
+	case StaticFunction:
+		return "StaticFunction"
+	case Virtual:
+		return "Virtual"
 	case Function:
 		return "Function"
+	case PrivateFunction:
+		return "PrivateFunction"
 	case Unknown:
 		return "Unknown"
+	case EndComments:
+		return "EndComments"
+	case Region:
+		return "Region"
+	case Positional:
+		return "Positional"
 	default:
 		return "Invalid"
 	}
 }
+
+// BlockTypeFromString is the inverse of BlockTypeToString, ignoring case and underscores
+func BlockTypeFromString(name string) (BlockType, bool) {
+	name = strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	for bt := BlockType(0); bt <= Unknown; bt++ {
+		if strings.ToLower(BlockTypeToString(bt)) == name {
+			return bt, true
+		}
+	}
+	return Unknown, false
+}
@@ -0,0 +1,63 @@
+package tokeniser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tk "godot_linter/styler/tokendef"
+)
+
+// FuzzTokenize checks Tokenize never panics and keeps every non blank line when it succeeds.
+// The format package's sample scripts seed the corpus.
+func FuzzTokenize(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("..", "..", "format", "testdata", "*", "*.gd"))
+	more, _ := filepath.Glob(filepath.Join("..", "..", "format", "testdata", "*", "*", "input.gd"))
+	for _, path := range append(paths, more...) {
+		if src, err := os.ReadFile(path); err == nil {
+			f.Add(string(src))
+		}
+	}
+	f.Add("static")
+	f.Add("\t\t\n#\n\"\"\"")
+	f.Add("#region R\nvar a = 1\n#endregion\n\t# indented note")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		// Counted before tokenising, which must not change the lines either
+		lines := strings.Split(src, "\n")
+		counts := map[string]int{}
+		for _, line := range lines {
+			if line := strings.TrimSpace(line); line != "" {
+				counts[line]++
+			}
+		}
+
+		blocks, err := Tokenize(lines)
+		if err != nil {
+			return
+		}
+		countBlockLines(blocks, counts)
+
+		for line, n := range counts {
+			if n > 0 {
+				t.Fatalf("line lost: %q", line)
+			} else if n < 0 {
+				t.Fatalf("line duplicated: %q", line)
+			}
+		}
+	})
+}
+
+// countBlockLines takes every non blank line of the blocks off counts
+func countBlockLines(blocks []tk.Block, counts map[string]int) {
+	for _, block := range blocks {
+		for _, line := range slices.Concat(block.Content, block.End) {
+			if line := strings.TrimSpace(line); line != "" {
+				counts[line]--
+			}
+		}
+		countBlockLines(block.Children, counts)
+	}
+}
@@ -1,13 +1,21 @@
 package tokeniser
 
 import (
+	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
+
+	"godot_linter/styler/lexer"
 )
 
 // Public API: ConvertSpaceIndentsToTabs takes lines of text and returns lines with space indents replaced by tabs.
 func ConvertSpaceIndentsToTabs(lines []string) []string {
-	indentSizes := getIndentSizes(lines)
+	// Lines inside multi-line strings are part of the string
+	tokens, _ := lexer.Lex(strings.Join(lines, "\n"))
+	inString := lexer.StringLines(tokens, len(lines))
+
+	indentSizes := getIndentSizes(lines, inString)
 	if len(indentSizes) == 0 {
 		return lines // nothing to convert
 	}
@@ -18,18 +26,55 @@ func ConvertSpaceIndentsToTabs(lines []string) []string {
 	}
 
 	var converted []string
-	for _, line := range lines {
-		converted = append(converted, convertLine(line, indentUnit))
+	for i, line := range lines {
+		if inString[i] {
+			converted = append(converted, line)
+		} else {
+			converted = append(converted, convertLine(line, indentUnit))
+		}
 	}
 	return converted
 }
 
+// Public API: ReindentComments indents comment lines that are indented less than the code below them to its indent,
+// lining unindented comments inside a body up with it.
+func ReindentComments(lines []string) []string {
+	src, err := newSource(lines)
+	if err != nil {
+		return lines
+	}
+
+	out := slices.Clone(lines)
+	below := "" // indent of the code below
+	for i := len(src.logical) - 1; i >= 0; i-- {
+		line := src.logical[i]
+		text := lines[line.start]
+		switch {
+		case line.blank():
+		case line.isComment():
+			if len(leadingSpace(text)) < len(below) {
+				out[line.start] = below + strings.TrimLeft(text, " \t")
+			}
+		default:
+			below = leadingSpace(text)
+		}
+	}
+	return out
+}
+
 // --- Internal helpers ---
 
-// getIndentSizes scans lines to extract leading space counts (excluding empty and unindented lines)
-func getIndentSizes(lines []string) []int {
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// getIndentSizes scans lines to extract leading space counts (excluding empty, unindented, tab indented and string lines)
+func getIndentSizes(lines []string, inString []bool) []int {
 	var sizes []int
-	for _, line := range lines {
+	for i, line := range lines {
+		if inString[i] || !hasSpaceIndent(line) {
+			continue
+		}
 		spaceCount := countLeadingSpaces(line)
 		if spaceCount > 0 {
 			sizes = append(sizes, spaceCount)
@@ -38,6 +83,12 @@ func getIndentSizes(lines []string) []int {
 	return sizes
 }
 
+// hasSpaceIndent reports whether a non blank line has spaces in its indent
+func hasSpaceIndent(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return trimmed != "" && strings.Contains(line[:len(line)-len(trimmed)], " ")
+}
+
 // countLeadingSpaces returns the number of leading space characters
 func countLeadingSpaces(s string) int {
 	count := 0
@@ -55,7 +106,21 @@ func countLeadingSpaces(s string) int {
 }
 
 // convertLine replaces leading spaces with tabs according to indent unit
+// originalCol maps a 1-based column of a converted line back to the line it was converted from,
+// only the leading whitespace differs between them
+func originalCol(col int, original, converted string) int {
+	indent := utf8.RuneCountInString(converted) - utf8.RuneCountInString(strings.TrimLeftFunc(converted, unicode.IsSpace))
+	if col <= indent {
+		return col
+	}
+	return col - indent + utf8.RuneCountInString(original) - utf8.RuneCountInString(strings.TrimLeftFunc(original, unicode.IsSpace))
+}
+
 func convertLine(line string, indentUnit int) string {
+	if !hasSpaceIndent(line) {
+		return line
+	}
+
 	spaceCount := countLeadingSpaces(line)
 	nTabs := spaceCount / indentUnit
 	nSpaces := spaceCount % indentUnit
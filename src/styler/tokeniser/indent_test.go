@@ -37,6 +37,21 @@ func TestConvertSpaceIndentsToTabs(t *testing.T) {
 				"\t\t\tlevel3",
 			},
 		},
+		{
+			name: "Blank and tab indented lines are kept",
+			input: []string{
+				" ",
+				"var a",
+				"\tvalue",
+				"  level1",
+			},
+			expected: []string{
+				" ",
+				"var a",
+				"\tvalue",
+				"\tlevel1",
+			},
+		},
 		{
 			name: "No indentation",
 			input: []string{
@@ -58,3 +73,31 @@ func TestConvertSpaceIndentsToTabs(t *testing.T) {
 		})
 	}
 }
+
+func TestReindentComments(t *testing.T) {
+	input := []string{
+		"# about f",
+		"func f():",
+		"# first",
+		"\tif a:",
+		"\t# inside if",
+		"\t\tpass",
+		"# end of f",
+		"func g():",
+	}
+	expected := []string{
+		"# about f",
+		"func f():",
+		"\t# first",
+		"\tif a:",
+		"\t\t# inside if",
+		"\t\tpass",
+		"# end of f",
+		"func g():",
+	}
+
+	actual := ReindentComments(input)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ReindentComments failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", input, expected, actual)
+	}
+}
@@ -0,0 +1,170 @@
+package tokeniser
+
+import (
+	"strings"
+
+	"godot_linter/styler/lexer"
+)
+
+// logicalLine is one statement of the script as the lexer sees it. Brackets,
+// line continuations and multi-line strings make it span several physical lines.
+type logicalLine struct {
+	start, end int  // physical line range, inclusive
+	indent     int  // indent tabs of the first physical line
+	empty      bool // only whitespace, lines of only a continuation have no tokens but aren't empty
+	tokens     []lexer.Token
+}
+
+func (ll logicalLine) blank() bool {
+	return ll.empty
+}
+
+func (ll logicalLine) isComment() bool {
+	return len(ll.tokens) > 0 && ll.tokens[0].Kind == lexer.Comment
+}
+
+// opensBlock reports whether the line ends with a `:` opening an indented block, such as a property's accessors
+func (ll logicalLine) opensBlock() bool {
+	toks := ll.tokens
+	if n := len(toks); n > 0 && toks[n-1].Kind == lexer.Comment {
+		toks = toks[:n-1]
+	}
+	n := len(toks)
+	return n > 0 && toks[n-1].Is(lexer.Punctuation, ":") && toks[n-1].Depth == 0
+}
+
+// key returns the normalised leading token that decides which handler reads the line,
+// member annotations are skipped and a line of only member annotations, `@export` and `@onready` included, has the key "@".
+func (ll logicalLine) key() string {
+	if len(ll.tokens) == 0 {
+		return ""
+	}
+	if ll.isComment() {
+		return commentKey(ll.tokens[0].Text)
+	}
+
+	if rest := skipAnnotations(ll.tokens, isMemberAnnotation); len(rest) == 0 || rest[0].Kind == lexer.Comment {
+		return "@"
+	}
+
+	toks := ll.declaration()
+
+	first := toks[0]
+	switch {
+	case first.Kind == lexer.String && strings.HasPrefix(first.Text, `"""`):
+		return `"""`
+	case first.Kind == lexer.Annotation && strings.HasPrefix(first.Text, "@export"):
+		return "@export"
+	case first.Is(lexer.Keyword, "static") && len(toks) > 1:
+		return "static " + toks[1].Text
+	}
+	return first.Text
+}
+
+// commentKey returns "#region" or "#endregion" for region markers, "##" for doc comments and "#" for other comments
+func commentKey(comment string) string {
+	if strings.HasPrefix(comment, "##") {
+		return "##"
+	}
+	for _, marker := range []string{"#region", "#endregion"} {
+		rest, ok := strings.CutPrefix(comment, marker)
+		if ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return marker
+		}
+	}
+	return "#"
+}
+
+// declaration returns the tokens after the leading member annotations, like `@rpc(...)` or `@warning_ignore(...)`.
+// Annotations with a handler of their own are kept.
+func (ll logicalLine) declaration() []lexer.Token {
+	return skipAnnotations(ll.tokens, func(annotation string) bool {
+		return !hasHandler(annotation)
+	})
+}
+
+// skipAnnotations returns the tokens after the leading annotations skip reports true for, with their arguments
+func skipAnnotations(toks []lexer.Token, skip func(annotation string) bool) []lexer.Token {
+	for len(toks) > 0 && toks[0].Kind == lexer.Annotation && skip(toks[0].Text) {
+		toks = toks[1:]
+		if len(toks) == 0 || !toks[0].Is(lexer.Punctuation, "(") {
+			continue
+		}
+
+		depth := toks[0].Depth
+		i := 1
+		for i < len(toks) && !(toks[i].Depth == depth && toks[i].Is(lexer.Punctuation, ")")) {
+			i++
+		}
+		toks = toks[min(i+1, len(toks)):]
+	}
+	return toks
+}
+
+// hasHandler reports whether an annotation opens a block, rather than decorating the member after it
+func hasHandler(annotation string) bool {
+	switch annotation {
+	case "@tool", "@icon", "@static_unload", "@onready", "@warning_ignore_start", "@warning_ignore_restore":
+		return true
+	}
+	return strings.HasPrefix(annotation, "@export")
+}
+
+// isMemberAnnotation reports whether an annotation belongs to the member after it, which may be on the next line.
+// Class annotations, the export groups and the warning ranges stand on their own.
+func isMemberAnnotation(annotation string) bool {
+	switch annotation {
+	case "@tool", "@icon", "@static_unload", "@export_group", "@export_subgroup", "@export_category",
+		"@warning_ignore_start", "@warning_ignore_restore":
+		return false
+	}
+	return true
+}
+
+// source pairs the physical lines of a script with its logical lines.
+type source struct {
+	lines    []string
+	logical  []logicalLine
+	offset   int    // line of the script the first line is at
+	unknown  *[]int // script lines of unknown blocks, shared with inner classes
+	inString []bool // lines starting inside a multi-line string
+}
+
+func newSource(lines []string) (*source, error) {
+	tokens, err := lexer.Lex(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	src := &source{lines: lines, inString: lexer.StringLines(tokens, len(lines))}
+	cur := logicalLine{start: 0}
+	for _, t := range tokens {
+		switch t.Kind {
+		case lexer.Indent, lexer.Dedent, lexer.EOF:
+			continue
+		case lexer.Newline:
+			cur.end = t.Line - 1
+			src.add(cur)
+			cur = logicalLine{start: t.Line}
+		default:
+			cur.tokens = append(cur.tokens, t)
+		}
+	}
+	if cur.start < len(lines) {
+		cur.end = len(lines) - 1
+		src.add(cur)
+	}
+
+	return src, nil
+}
+
+func (src *source) add(ll logicalLine) {
+	ll.indent = countIndent(src.lines[ll.start])
+	ll.empty = len(ll.tokens) == 0 && strings.TrimSpace(strings.Join(src.lines[ll.start:ll.end+1], "")) == ""
+	src.logical = append(src.logical, ll)
+}
+
+// text returns the physical lines covered by logical lines from to to, inclusive.
+func (src *source) text(from, to int) []string {
+	return src.lines[src.logical[from].start : src.logical[to].end+1]
+}
@@ -2,83 +2,140 @@ package tokeniser
 
 import (
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
+	"unicode/utf8"
 
+	"godot_linter/styler/lexer"
 	tk "godot_linter/styler/tokendef"
 )
 
 const indent = "	"
 
-type HandlerFunc func(line string, lines []string, i *int, blocks *[]tk.Block, linkedAbove *[]string)
-
-var handlers = map[string]HandlerFunc{
-	"@tool":      handleTool,
-	"class_name": handleClassName,
-	"extends":    handleExtend,
-	`"""`:        handleDocString,
-	"signal":     handleSignals,
-	"enum":       handleEnum,
-	"const":      handleConstants,
-	"@export":    handleExport,
-	"@onready":   handleOnReady,
-	"class":      handleClass,
-	"static":     handleStatic,
-	"var":        handleVar,
-	"func":       handleFunction,
-	"#":          handleComment,
+type HandlerFunc func(src *source, i *int, blocks *[]tk.Block, linkedAbove *[]string)
+
+var handlers map[string]HandlerFunc
+
+// Assigned in init as handleClass tokenises class bodies through the map
+func init() {
+	handlers = map[string]HandlerFunc{
+		"@tool":          handleTool,
+		"@icon":          handleClassAnnotation,
+		"@static_unload": handleClassAnnotation,
+		"@":              handleAnnotation,
+
+		"@warning_ignore_start":   handlePositional,
+		"@warning_ignore_restore": handlePositional,
+
+		"#region":     handleRegion,
+		"#endregion":  handleComment,
+		"class_name":  handleClassName,
+		"extends":     handleExtend,
+		`"""`:         handleDocString,
+		"signal":      handleSignals,
+		"enum":        handleEnum,
+		"const":       handleConstants,
+		"@export":     handleExport,
+		"@onready":    handleOnReady,
+		"class":       handleClass,
+		"static var":  handleStaticVar,
+		"static func": handleStaticFunction,
+		"var":         handleVar,
+		"func":        handleFunction,
+		"#":           handleComment,
+		"##":          handleDocComment,
+	}
+}
+
+// UnknownLine is a line of the script no handler recognised, Line and Col are 1-based
+type UnknownLine struct {
+	Line int
+	Col  int
+	Text string
+}
+
+// UnknownError lists every line of the script no handler recognised
+type UnknownError struct {
+	Lines []UnknownLine
+}
+
+func (uerr *UnknownError) Error() string {
+	if len(uerr.Lines) == 0 {
+		return "Unknown component in script"
+	}
+	first := uerr.Lines[0]
+	return fmt.Sprintf("Unknown component in script at %d:%d: %s", first.Line, first.Col, first.Text)
 }
 
 func Tokenize(lines []string) ([]tk.Block, error) {
-	lines = ConvertSpaceIndentsToTabs(lines)
+	var unknown []int
+	converted := ConvertSpaceIndentsToTabs(lines)
+	blocks, err := tokenize(converted, 0, &unknown)
+
+	var lerr lexer.ErrorList
+	if errors.As(err, &lerr) {
+		// The lexer saw the converted indents, point at the lines as written
+		for _, e := range lerr {
+			if e.Line >= 1 && e.Line <= len(lines) {
+				e.Col = originalCol(e.Col, lines[e.Line-1], converted[e.Line-1])
+			}
+		}
+	}
+	if err != nil || len(unknown) == 0 {
+		return blocks, err
+	}
+
+	slices.Sort(unknown)
+	uerr := &UnknownError{}
+	for _, l := range unknown {
+		text := strings.TrimSpace(lines[l])
+		col := utf8.RuneCountInString(lines[l][:strings.Index(lines[l], text)]) + 1
+		uerr.Lines = append(uerr.Lines, UnknownLine{Line: l + 1, Col: col, Text: text})
+	}
+	return blocks, uerr
+}
+
+// tokenize splits tab indented lines into blocks, inner classes are tokenised recursively.
+// offset is the line of the script lines starts at, unknown collects the lines of unknown blocks.
+func tokenize(lines []string, offset int, unknown *[]int) ([]tk.Block, error) {
+	src, err := newSource(lines)
+	if err != nil {
+		return nil, err
+	}
+	src.offset = offset
+	src.unknown = unknown
 
 	var blocks []tk.Block
-	blocks = make([]tk.Block, 0, len(lines)/2)
+	blocks = make([]tk.Block, 0, len(src.logical)/2)
 
 	var linked_above []string
 
-	unknown_component := false
+	for i := 0; i < len(src.logical); i++ {
+		line := src.logical[i]
 
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-
-		fn, ok := handlers[strings.Split(line, " ")[0]]
-		if ok {
-			fn(line, lines, &i, &blocks, &linked_above)
-		} else {
-			// Cases not recognised by first character
-			switch {
-			case isIndentOnly(line):
-				continue
-			case strings.HasPrefix(line, "@export"): // Other exports
-				handleExport(line, lines, &i, &blocks, &linked_above)
-			case line[0] == '#', stripIndents(&line)[0] == '#':
-				handleComment(line, lines, &i, &blocks, &linked_above)
-			default:
-				handleUnknown(line, lines, &i, &blocks, &linked_above)
-				unknown_component = true
-			}
+		if line.blank() {
+			continue
 		}
-	}
 
-	// Scan for unknown component
-	if !unknown_component {
-		for _, block := range blocks {
-			if block.Type == tk.Unknown {
-				unknown_component = true
-				break
-			}
+		fn, ok := handlers[line.key()]
+		switch {
+		case ok && line.indent == 0:
+			fn(src, &i, &blocks, &linked_above)
+		case line.isComment() && (len(linked_above) > 0 || len(blocks) > 0):
+			handleIndentedComment(src, &i, &blocks, &linked_above)
+		default:
+			// Stray indented code has no block to belong to
+			handleUnknown(src, &i, &blocks, &linked_above)
 		}
 	}
 
-	var e error
-	if unknown_component {
-		e = errors.New("Unknown component in script")
-	} else {
-		e = nil
+	if len(linked_above) > 0 {
+		// Comments below the last member
+		blocks = append(blocks, makeBlock(tk.EndComments, linked_above))
 	}
 
-	return blocks, e
+	return blocks, nil
 }
 
 // countIndent counts how many indent tabs at line start.
@@ -98,87 +155,104 @@ func consumeWithAbove(linked_above *[]string, content ...string) []string {
 
 }
 
-// findBlockEnd finds the last line index of a func/class by indent level.
-func findBlockEnd(lines []string, idx int) int {
-	baseIndent := countIndent(lines[idx])
-	i := idx + 1
-	for ; i < len(lines); i++ {
-		if countIndent(lines[i]) <= baseIndent && strings.TrimSpace(lines[i]) != "" {
-			break
+// findBlockEnd finds the last logical line of a func/class by indent level.
+// Unindented comments don't end the block, they are part of it when more of the body follows.
+func findBlockEnd(src *source, idx int) int {
+	baseIndent := src.logical[idx].indent
+	end := idx
+	unindented := false // comments below the body so far are unindented
+	for i := idx + 1; i < len(src.logical); i++ {
+		line := src.logical[i]
+		switch {
+		case line.blank():
+		case line.isComment() && (unindented || line.indent <= baseIndent):
+			unindented = true
+		case line.indent > baseIndent:
+			end = i
+			unindented = false
+		default:
+			return end
 		}
 	}
-	return i - 1
+	return end
 }
 
-// findBlockEnd finds the last line index of a func/class by finding the start of the next block
-func findImplicitBlockEnd(lines []string, idx int) int {
-	i := idx + 1
+// startsBlock reports whether a logical line is at the top level, where every line starts a block or is unknown.
+func startsBlock(line logicalLine) bool {
+	return !line.blank() && line.indent == 0
+}
 
-outer:
-	for ; i < len(lines); i++ {
-		for _, pre := range tk.Prefixes {
-			if strings.HasPrefix(lines[i], pre) {
-				break outer
-			}
+// findImplicitBlockEnd finds the last logical line of a block by finding the start of the next block
+func findImplicitBlockEnd(src *source, idx int) int {
+	i := idx + 1
+	for ; i < len(src.logical); i++ {
+		if startsBlock(src.logical[i]) {
+			break
 		}
 	}
 	return i - 1
 }
 
-// findBlockEnd finds the last line index of a func/class by finding the start of the next block, extends the original type
-func findImplicitExtendedBlockEnd(lines []string, idx int, exception string) int {
+// findImplicitExtendedBlockEnd finds the last logical line of a block by finding the start of the next block,
+// extends the block over following lines of the same kind unless a blank line separates them
+func findImplicitExtendedBlockEnd(src *source, idx int, exception string) int {
 	i := idx + 1
 	hadBlank := false
 
-outer:
-	for ; i < len(lines); i++ {
-		for _, pre := range tk.Prefixes {
-			if isIndentOnly(lines[i]) {
-				hadBlank = true
-				goto nl
-			}
+	for ; i < len(src.logical); i++ {
+		line := src.logical[i]
 
-			if strings.HasPrefix(lines[i], pre) {
-				if strings.HasPrefix(lines[i], exception) && hadBlank {
-					// exception prefix after blank line(s)
-					break outer
-				} else if strings.HasPrefix(lines[i], exception) {
-					// exception prefix without blank line
-					goto nl
-				} else {
-					// non-exception prefix
-					break outer
-				}
-			}
+		if line.blank() {
+			hadBlank = true
+			continue
 		}
 
-	nl:
-		continue
+		if !startsBlock(line) {
+			continue
+		}
+
+		if line.key() != exception || hadBlank {
+			break
+		}
 	}
 	return i - 1
 }
 
-// // Find when a segment of lines that start with `id` ends
-// func findSegmentEnd(lines []string, idx int, id string) int {
-// 	i := idx + 1
-// 	for ; i < len(lines); i++ {
-// 		// stop when indent back to <= base or blank
-// 		if !strings.HasPrefix(lines[i], id) {
-// 			return i - 1
-// 		}
-// 	}
-// 	return i
-// }
+// findAccessorEnd finds the last logical line of the accessor block of a property declaration,
+// comments and blank lines below the accessors are left out. Other declarations end where they start.
+func findAccessorEnd(src *source, idx int) int {
+	decl := src.logical[idx]
+	end := idx
+	if !decl.opensBlock() {
+		return end
+	}
 
-func find_docstring_end(lines []string, idx int) int {
-	i := idx + 1
-	for ; i < len(lines); i++ {
-		if strings.HasSuffix(strings.TrimSpace(lines[i]), "\"\"\"") {
-			return i
+	for i := idx + 1; i < len(src.logical); i++ {
+		line := src.logical[i]
+		if line.blank() || line.isComment() {
+			continue
 		}
+		if line.indent <= decl.indent {
+			break
+		}
+		end = i
 	}
-	// didn't find closer → just return start
-	return idx
+	return end
+}
+
+// findDeclarationsEnd finds the last logical line of a run of declarations starting with key,
+// the accessor blocks of properties included. A blank line ends the run.
+func findDeclarationsEnd(src *source, idx int, key string) int {
+	end := findAccessorEnd(src, idx)
+	for i := end + 1; i < len(src.logical); i++ {
+		line := src.logical[i]
+		if line.blank() || line.indent != 0 || line.key() != key {
+			break
+		}
+		end = findAccessorEnd(src, i)
+		i = end
+	}
+	return end
 }
 
 func makeBlock(btype tk.BlockType, lines []string) tk.Block {
@@ -186,6 +260,19 @@ func makeBlock(btype tk.BlockType, lines []string) tk.Block {
 	return block
 }
 
+// dedentLines strips one indent from every line, blank lines and lines inside strings are kept as is.
+func dedentLines(lines []string, inString []bool) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if inString[i] {
+			out[i] = line
+		} else {
+			out[i] = strings.TrimPrefix(line, indent)
+		}
+	}
+	return out
+}
+
 func trimBlankLines(lines []string) []string {
 	start := 0
 	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
@@ -202,147 +289,305 @@ func trimBlankLines(lines []string) []string {
 
 // ---- Handler implementations ----
 
-func handleTool(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
+func handleTool(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
 	*blocks = append(*blocks, makeBlock(tk.Tool,
-		consumeWithAbove(linkedAbove, line),
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
+	))
+}
+func handleClassAnnotation(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	*blocks = append(*blocks, makeBlock(tk.ClassAnnotation,
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
 }
-func handleClassName(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
+func handleClassName(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
 	*blocks = append(*blocks, makeBlock(tk.ClassName,
-		consumeWithAbove(linkedAbove, line),
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
 }
-func handleExtend(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
+func handleExtend(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
 	*blocks = append(*blocks, makeBlock(tk.Extend,
-		consumeWithAbove(linkedAbove, line),
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
 }
-func handleDocString(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := find_docstring_end(lines, *idx)
-	docLines := lines[*idx : end+1]
+func handleDocString(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
 	*blocks = append(*blocks, makeBlock(tk.DocString,
-		consumeWithAbove(linkedAbove, docLines...),
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
-	*idx = end
 }
-func handleSignals(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
+func handleSignals(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
 	*blocks = append(*blocks, makeBlock(tk.Signals,
-		consumeWithAbove(linkedAbove, line),
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
 }
-func handleEnum(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitBlockEnd(lines, *idx)
-	enumLines := lines[*idx : end+1]
+func handleEnum(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	end := findImplicitBlockEnd(src, *idx)
 	*blocks = append(*blocks, makeBlock(tk.Enum,
-		trimBlankLines(consumeWithAbove(linkedAbove, enumLines...)),
+		trimBlankLines(consumeWithAbove(linkedAbove, src.text(*idx, end)...)),
 	))
 	*idx = end
 }
-func handleConstants(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitExtendedBlockEnd(lines, *idx, "const")
-	constLines := lines[*idx : end+1]
+func handleConstants(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	end := findImplicitExtendedBlockEnd(src, *idx, "const")
 	*blocks = append(*blocks, makeBlock(tk.Constants,
-		trimBlankLines(consumeWithAbove(linkedAbove, constLines...)),
+		trimBlankLines(consumeWithAbove(linkedAbove, src.text(*idx, end)...)),
 	))
 	*idx = end
 }
-func handleExport(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitExtendedBlockEnd(lines, *idx, "@export")
-	fnLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Export,
-		trimBlankLines(consumeWithAbove(linkedAbove, fnLines...)),
-	))
+func handleExport(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	handleDeclarations(tk.Export, "@export", src, idx, blocks, linkedAbove)
+}
+func handleOnReady(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	handleDeclarations(tk.Onready, "@onready", src, idx, blocks, linkedAbove)
+}
+func handleClass(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	end := findBlockEnd(src, *idx)
+	block := makeBlock(tk.Class,
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
+	)
+
+	if end > *idx {
+		lines := src.text(*idx+1, end)
+		body := trimBlankLines(lines)
+		if len(body) > 0 {
+			first := src.logical[*idx+1].start + slices.Index(lines, body[0])
+			children, err := tokenize(dedentLines(body, src.inString[first:]), src.offset+first, src.unknown)
+			if err != nil {
+				// Body can't be lexed on its own, keep the class as one opaque block
+				block.Content = slices.Concat(block.Content, body)
+			} else {
+				block.Children = children
+			}
+		}
+	}
+
+	*blocks = append(*blocks, block)
 	*idx = end
 }
-func handleOnReady(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitExtendedBlockEnd(lines, *idx, "@onready")
-	fnLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Onready,
-		trimBlankLines(consumeWithAbove(linkedAbove, fnLines...)),
-	))
+
+// handleRegion reads the members up to the matching `#endregion` into a region block,
+// a region without an end is a plain comment
+func handleRegion(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	end := findRegionEnd(src, *idx)
+	if end < 0 {
+		handleComment(src, idx, blocks, linkedAbove)
+		return
+	}
+
+	block := makeBlock(tk.Region, consumeWithAbove(linkedAbove, src.text(*idx, *idx)...))
+	block.End = src.text(end, end)
+
+	first, last := src.logical[*idx].end+1, src.logical[end].start
+	children, err := tokenize(src.lines[first:last], src.offset+first, src.unknown)
+	if err != nil {
+		// Body can't be lexed on its own, keep the region as one opaque block
+		block.Content = slices.Concat(block.Content, src.lines[first:last])
+	} else {
+		block.Children = children
+	}
+
+	*blocks = append(*blocks, block)
 	*idx = end
 }
-func handleClass(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findBlockEnd(lines, *idx)
-	classLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Class,
-		consumeWithAbove(linkedAbove, classLines...),
-	))
-	*idx = end
+
+// findRegionEnd finds the logical line of the `#endregion` closing the region at idx, or -1
+func findRegionEnd(src *source, idx int) int {
+	depth := 0
+	for i := idx; i < len(src.logical); i++ {
+		line := src.logical[i]
+		if line.indent != 0 {
+			continue
+		}
+		switch line.key() {
+		case "#region":
+			depth++
+		case "#endregion":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
 }
-func handleStatic(line string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	switch line[7] {
-	case 'v':
-		handleStaticVar_(line, lines, idx, blocks, linkedAbove)
-	case 'f':
-		handleStaticFunction_(line, lines, idx, blocks, linkedAbove)
-	default:
-		handleUnknown(line, lines, idx, blocks, linkedAbove)
-	}
-}
-func handleStaticVar_(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitExtendedBlockEnd(lines, *idx, "static var")
-	varLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.LocalVar,
-		trimBlankLines(consumeWithAbove(linkedAbove, varLines...)),
-	))
+func handleStaticVar(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	handleDeclarations(tk.StaticVar, "static var", src, idx, blocks, linkedAbove)
+}
+
+// handleStaticFunction reads a static method, `_static_init` is ordered before the others
+func handleStaticFunction(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	order := 1
+	if decl := src.logical[*idx].declaration(); len(decl) > 2 && decl[2].Text == "_static_init" {
+		order = 0
+	}
+
+	end := findBlockEnd(src, *idx)
+	block := makeBlock(tk.StaticFunction,
+		trimBlankLines(consumeWithAbove(linkedAbove, src.text(*idx, end)...)),
+	)
+	block.Order = order
+	*blocks = append(*blocks, block)
 	*idx = end
 }
-func handleStaticFunction_(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findBlockEnd(lines, *idx)
-	fnLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Function,
-		trimBlankLines(consumeWithAbove(linkedAbove, fnLines...)),
-	))
+
+// handleVar reads a run of declarations, or a single one annotated with `@export` or `@onready` on the lines above
+func handleVar(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	btype := annotatedType(*linkedAbove)
+	if btype == tk.LocalVar {
+		handleDeclarations(tk.LocalVar, "var", src, idx, blocks, linkedAbove)
+		return
+	}
+
+	addDeclarations(btype, findAccessorEnd(src, *idx), src, idx, blocks, linkedAbove)
+}
+
+// annotatedType returns Export or Onready when the lines linked to a declaration hold its `@export` or `@onready`,
+// otherwise LocalVar
+func annotatedType(linkedAbove []string) tk.BlockType {
+	tokens, err := lexer.Lex(strings.Join(linkedAbove, "\n"))
+	if err != nil {
+		return tk.LocalVar
+	}
+	for _, t := range tokens {
+		switch {
+		case t.Kind != lexer.Annotation:
+		case strings.HasPrefix(t.Text, "@export"):
+			return tk.Export
+		case t.Text == "@onready":
+			return tk.Onready
+		}
+	}
+	return tk.LocalVar
+}
+func handleFunction(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	btype, order := tk.Function, 0
+
+	if decl := src.logical[*idx].declaration(); len(decl) > 1 {
+		name := decl[1].Text
+		if i := slices.Index(tk.VirtualMethods, name); i >= 0 {
+			btype, order = tk.Virtual, i
+		} else if strings.HasPrefix(name, "_") {
+			btype = tk.PrivateFunction
+		}
+	}
+
+	end := findBlockEnd(src, *idx)
+	block := makeBlock(btype,
+		trimBlankLines(consumeWithAbove(linkedAbove, src.text(*idx, end)...)),
+	)
+	block.Order = order
+	*blocks = append(*blocks, block)
 	*idx = end
 }
-func handleVar(_ string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findImplicitExtendedBlockEnd(lines, *idx, "var")
-	varLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.LocalVar,
-		trimBlankLines(consumeWithAbove(linkedAbove, varLines...)),
-	))
+
+// handleDeclarations reads a run of declarations starting with key into one block,
+// the accessors of properties stay with their declaration
+func handleDeclarations(btype tk.BlockType, key string, src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	addDeclarations(btype, findDeclarationsEnd(src, *idx, key), src, idx, blocks, linkedAbove)
+}
+
+// addDeclarations adds the declarations up to the logical line end as one block, marking its properties
+func addDeclarations(btype tk.BlockType, end int, src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	block := makeBlock(btype, consumeWithAbove(linkedAbove, src.text(*idx, end)...))
+	block.Properties = Properties(block.Content)
+	*blocks = append(*blocks, block)
 	*idx = end
 }
-func handleFunction(line string, lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	if strings.HasPrefix(line, "func _init(") {
-		handleInit_(lines, idx, blocks, linkedAbove)
-		return
-	} else if strings.HasPrefix(line, "func _ready(") {
-		handleReady_(lines, idx, blocks, linkedAbove)
+
+// Properties finds the declarations with an accessor block in the lines of a block of declarations
+func Properties(lines []string) []tk.Property {
+	src, err := newSource(lines)
+	if err != nil {
+		return nil
+	}
+
+	var properties []tk.Property
+	for i := 0; i < len(src.logical); i++ {
+		line := src.logical[i]
+		if line.indent != 0 || !line.opensBlock() {
+			continue
+		}
+		last := findAccessorEnd(src, i)
+		properties = append(properties, tk.Property{Start: line.start, End: src.logical[last].end})
+		i = last
+	}
+	return properties
+}
+func handleComment(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	*linkedAbove = append(*linkedAbove, src.text(*idx, *idx)...)
+}
+
+// handleDocComment reads a run of `##` comments before the first member as the class documentation,
+// unless it is directly above a member it documents. Other doc comments are linked like comments.
+func handleDocComment(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	end := *idx
+	for end+1 < len(src.logical) && src.logical[end+1].indent == 0 && src.logical[end+1].key() == "##" {
+		end++
+	}
+
+	if !isClassDoc(src, *blocks, end) {
+		*linkedAbove = append(*linkedAbove, src.text(*idx, end)...)
+		*idx = end
 		return
 	}
 
-	end := findBlockEnd(lines, *idx)
-	fnLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Function,
-		trimBlankLines(consumeWithAbove(linkedAbove, fnLines...)),
+	*blocks = append(*blocks, makeBlock(tk.ClassDoc,
+		consumeWithAbove(linkedAbove, src.text(*idx, end)...),
 	))
 	*idx = end
 }
-func handleInit_(lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findBlockEnd(lines, *idx)
-	initLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Init,
-		trimBlankLines(consumeWithAbove(linkedAbove, initLines...)),
-	))
-	*idx = end
+
+// isClassDoc reports whether the doc comment ending at end documents the class
+func isClassDoc(src *source, blocks []tk.Block, end int) bool {
+	for _, b := range blocks {
+		if !slices.Contains([]tk.BlockType{tk.Tool, tk.ClassAnnotation, tk.ClassName, tk.Extend}, b.Type) {
+			return false
+		}
+	}
+
+	// Indented comments below stay with the doc comment, see handleIndentedComment
+	i := end + 1
+	for i < len(src.logical) && src.logical[i].indent > 0 && src.logical[i].isComment() {
+		i++
+	}
+	if i == len(src.logical) {
+		return true
+	}
+	next := src.logical[i]
+	return next.blank() || slices.Contains([]string{"@tool", "@icon", "@static_unload", "class_name", "extends"}, next.key())
 }
-func handleReady_(lines []string, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	end := findBlockEnd(lines, *idx)
-	readyLines := lines[*idx : end+1]
-	*blocks = append(*blocks, makeBlock(tk.Ready,
-		trimBlankLines(consumeWithAbove(linkedAbove, readyLines...)),
+
+// handlePositional reads a line that applies to the lines below it, which keeps its place among the members
+func handlePositional(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	*blocks = append(*blocks, makeBlock(tk.Positional,
+		consumeWithAbove(linkedAbove, src.text(*idx, *idx)...),
 	))
-	*idx = end
 }
-func handleComment(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	*linkedAbove = append(*linkedAbove, line)
+
+// handleAnnotation links a line of member annotations to the member below, like a comment
+func handleAnnotation(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	*linkedAbove = append(*linkedAbove, src.text(*idx, *idx)...)
+}
+
+// handleIndentedComment keeps an indented comment in the comment run it is part of, or with the member above,
+// linking it to the member below would let sorting move it under a block that absorbs it.
+// Below a region it follows the `#endregion`.
+func handleIndentedComment(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	if len(*linkedAbove) > 0 {
+		handleComment(src, idx, blocks, linkedAbove)
+		return
+	}
+	// Content shares its array with the source lines, appending in place would overwrite the lines below
+	last := &(*blocks)[len(*blocks)-1]
+	if last.Type == tk.Region {
+		last.End = slices.Concat(last.End, src.text(*idx, *idx))
+		return
+	}
+	last.Content = slices.Concat(last.Content, src.text(*idx, *idx))
 }
-func handleUnknown(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAbove *[]string) {
-	//printer.PrintWarning("Unknown line parsed: " + line)
+func handleUnknown(src *source, idx *int, blocks *[]tk.Block, linkedAbove *[]string) {
+	*src.unknown = append(*src.unknown, src.offset+src.logical[*idx].start)
 	*blocks = append(*blocks, makeBlock(tk.Unknown,
-		trimBlankLines(consumeWithAbove(linkedAbove, line)),
+		trimBlankLines(consumeWithAbove(linkedAbove, src.text(*idx, *idx)...)),
 	))
 	flushAbove(linkedAbove)
 }
@@ -351,12 +596,3 @@ func handleUnknown(line string, _ []string, _ *int, blocks *[]tk.Block, linkedAb
 func flushAbove(linkedAbove *[]string) {
 	*linkedAbove = nil
 }
-
-func isIndentOnly(s string) bool {
-	return strings.Trim(s, " \t") == ""
-}
-
-func stripIndents(line *string) string {
-	return strings.TrimLeft(*line, indent)
-
-}
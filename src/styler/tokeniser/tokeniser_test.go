@@ -0,0 +1,247 @@
+package tokeniser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tk "godot_linter/styler/tokendef"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []tk.BlockType
+	}{
+		{
+			name:     "Hash inside string is not a comment",
+			input:    "extends Node\nvar a = \"# not a comment\"\nfunc f():\n\tpass",
+			expected: []tk.BlockType{tk.Extend, tk.LocalVar, tk.Function},
+		},
+		{
+			name:     "Triple quote key inside dictionary",
+			input:    "var dictionary = {\n\"\"\"key\"\"\" : 0\n}\nconst A = 1",
+			expected: []tk.BlockType{tk.LocalVar, tk.Constants},
+		},
+		{
+			name:     "Multi-line signal",
+			input:    "signal hit(\ndamage: int\n)\nsignal died",
+			expected: []tk.BlockType{tk.Signals, tk.Signals},
+		},
+		{
+			name:     "Unindented brackets and continuations",
+			input:    "const TABLE = {\n\"a\": [\n1,\n],\n}\nvar total = 1 + \\\n2\nenum State {\nIDLE,\n}\nfunc f(a,\nb):\n\tpass",
+			expected: []tk.BlockType{tk.Constants, tk.LocalVar, tk.Enum, tk.Function},
+		},
+		{
+			name:     "Unindented comment inside a function",
+			input:    "func f():\n\tvar a = 1\n# note\n\treturn a\n# about g\nfunc g():\n\tpass",
+			expected: []tk.BlockType{tk.Function, tk.Function},
+		},
+		{
+			name:     "Method groups",
+			input:    "func _helper():\n\tpass\nfunc _process(d):\n\tpass\nfunc run():\n\tpass",
+			expected: []tk.BlockType{tk.PrivateFunction, tk.Virtual, tk.Function},
+		},
+		{
+			name:     "Class annotations",
+			input:    "@tool\n@icon(\"res://icon.svg\")\n@static_unload\nextends Node",
+			expected: []tk.BlockType{tk.Tool, tk.ClassAnnotation, tk.ClassAnnotation, tk.Extend},
+		},
+		{
+			name:     "Class and member doc comments",
+			input:    "## Class docs\nextends Node\n## Member docs\nvar a\n## More member docs\nfunc f():\n\tpass",
+			expected: []tk.BlockType{tk.ClassDoc, tk.Extend, tk.LocalVar, tk.Function},
+		},
+		{
+			name:     "Member annotations",
+			input:    "@rpc(\"any_peer\")\nfunc _jump():\n\tpass\n@warning_ignore(\"unused\") @onready var a = $A\n@abstract func f()",
+			expected: []tk.BlockType{tk.PrivateFunction, tk.Onready, tk.Function},
+		},
+		{
+			name:     "Static members with extra spaces",
+			input:    "static  var a = 1\nstatic\tfunc f():\n\tpass\nstatic var b: int = 2\nstatic func _static_init() -> void:\n\tpass",
+			expected: []tk.BlockType{tk.StaticVar, tk.StaticFunction, tk.StaticVar, tk.StaticFunction},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := Tokenize(strings.Split(tt.input, "\n"))
+			if err != nil {
+				t.Fatalf("Tokenize returned error: %v", err)
+			}
+
+			var actual []tk.BlockType
+			var lines []string
+			for _, b := range blocks {
+				actual = append(actual, b.Type)
+				lines = append(lines, b.Content...)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Tokenize failed.\nInput:\n%v\nExpected:\n%v\nGot:\n%v", tt.input, tt.expected, actual)
+			}
+			if strings.Join(lines, "\n") != tt.input {
+				t.Errorf("Tokenize lost lines.\nInput:\n%v\nGot:\n%v", tt.input, strings.Join(lines, "\n"))
+			}
+		})
+	}
+}
+
+func TestTokenizeTrailingComments(t *testing.T) {
+	input := "func _ready():\n\tpass\n\n# end"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	if len(blocks) != 2 || blocks[1].Type != tk.EndComments || !reflect.DeepEqual(blocks[1].Content, []string{"# end"}) {
+		t.Errorf("trailing comment not kept: %v", blocks)
+	}
+}
+
+func TestTokenizeProperties(t *testing.T) {
+	input := "# Health\nvar health: int:\n\tset(value):\n\t\thealth = value\n\n\tget:\n\t\treturn health\nvar speed = 1\n\nfunc f():\n\tpass"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	if len(blocks) != 2 || blocks[0].Type != tk.LocalVar {
+		t.Fatalf("expected a var block and a function, got %v", blocks)
+	}
+	if len(blocks[0].Content) != 8 {
+		t.Errorf("accessors not kept with their declaration: %q", blocks[0].Content)
+	}
+	expected := []tk.Property{{Start: 1, End: 6}}
+	if !reflect.DeepEqual(blocks[0].Properties, expected) {
+		t.Errorf("unexpected properties.\nExpected:\n%v\nGot:\n%v", expected, blocks[0].Properties)
+	}
+}
+
+func TestTokenizeUnknown(t *testing.T) {
+	_, err := Tokenize([]string{"extends Node", "print('top level')"})
+	if err == nil {
+		t.Fatal("expected an error for top level code")
+	}
+}
+
+func TestTokenizeUnknownPositions(t *testing.T) {
+	input := "extends Node\nprint(1)\nclass A:\n\n    oops()\n    var x\nvar y"
+	_, err := Tokenize(strings.Split(input, "\n"))
+
+	var uerr *UnknownError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected an UnknownError, got %v", err)
+	}
+
+	expected := []UnknownLine{
+		{Line: 2, Col: 1, Text: "print(1)"},
+		{Line: 5, Col: 5, Text: "oops()"},
+	}
+	if !reflect.DeepEqual(uerr.Lines, expected) {
+		t.Errorf("unexpected unknown lines.\nExpected:\n%v\nGot:\n%v", expected, uerr.Lines)
+	}
+}
+
+func TestTokenizeUnknownAfterImplicitBlocks(t *testing.T) {
+	input := "const A = 1\nprint(1)\nenum E {\n\tX,\n}\nprint(2)"
+	_, err := Tokenize(strings.Split(input, "\n"))
+
+	var uerr *UnknownError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected an UnknownError, got %v", err)
+	}
+
+	expected := []UnknownLine{
+		{Line: 2, Col: 1, Text: "print(1)"},
+		{Line: 6, Col: 1, Text: "print(2)"},
+	}
+	if !reflect.DeepEqual(uerr.Lines, expected) {
+		t.Errorf("unexpected unknown lines.\nExpected:\n%v\nGot:\n%v", expected, uerr.Lines)
+	}
+}
+
+func TestTokenizeInnerClass(t *testing.T) {
+	input := "class A:\n\tfunc f():\n\t\tpass\n\n\tclass B:\n\t\tvar x\n\tsignal s\nvar y"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	if len(blocks) != 2 || blocks[0].Type != tk.Class {
+		t.Fatalf("expected a class and a var, got %v", blocks)
+	}
+
+	class := blocks[0]
+	if !reflect.DeepEqual(class.Content, []string{"class A:"}) {
+		t.Errorf("unexpected class header %q", class.Content)
+	}
+
+	var types []tk.BlockType
+	for _, c := range class.Children {
+		types = append(types, c.Type)
+	}
+	if !reflect.DeepEqual(types, []tk.BlockType{tk.Function, tk.Class, tk.Signals}) {
+		t.Errorf("unexpected class members %v", types)
+	}
+
+	inner := class.Children[1]
+	if len(inner.Children) != 1 || !reflect.DeepEqual(inner.Children[0].Content, []string{"var x"}) {
+		t.Errorf("nested class body not dedented: %v", inner.Children)
+	}
+}
+
+func TestTokenizeRegion(t *testing.T) {
+	input := "#region Stats\nvar a\n#region Inner\nsignal s\n#endregion\n#endregion\n#region Open\nvar b"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	if len(blocks) != 2 || blocks[0].Type != tk.Region || blocks[1].Type != tk.LocalVar {
+		t.Fatalf("expected a region and a var, got %v", blocks)
+	}
+
+	region := blocks[0]
+	if !reflect.DeepEqual(region.End, []string{"#endregion"}) || len(region.Children) != 2 || region.Children[1].Type != tk.Region {
+		t.Errorf("unexpected region %v", region)
+	}
+	if !reflect.DeepEqual(blocks[1].Content, []string{"#region Open", "var b"}) {
+		t.Errorf("region without an end should be a comment, got %q", blocks[1].Content)
+	}
+}
+
+func TestTokenizeRegionIndentedComment(t *testing.T) {
+	input := "#region R\nvar a = 1\n#endregion\n\t# indented note"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	region := blocks[0]
+	if !reflect.DeepEqual(region.Content, []string{"#region R"}) || !reflect.DeepEqual(region.End, []string{"#endregion", "\t# indented note"}) {
+		t.Errorf("comment should follow the #endregion, got %q and %q", region.Content, region.End)
+	}
+	if len(region.Children) != 1 || !reflect.DeepEqual(region.Children[0].Content, []string{"var a = 1"}) {
+		t.Errorf("unexpected region members %v", region.Children)
+	}
+}
+
+func TestTokenizeVirtualOrder(t *testing.T) {
+	input := "func _physics_process(d):\n\tpass\nfunc _init():\n\tpass\nfunc _ready():\n\tpass"
+	blocks, err := Tokenize(strings.Split(input, "\n"))
+	if err != nil {
+		t.Fatalf("Tokenize returned error: %v", err)
+	}
+
+	var orders []int
+	for _, b := range blocks {
+		orders = append(orders, b.Order)
+	}
+	if !reflect.DeepEqual(orders, []int{4, 0, 2}) {
+		t.Errorf("unexpected virtual method orders %v", orders)
+	}
+}